@@ -1,12 +1,15 @@
 package main
 
 import (
+	"expvar"
 	"github.com/joho/godotenv"
 	"github.com/squ1ky/talkify/internal/config"
 	"github.com/squ1ky/talkify/internal/database"
 	"github.com/squ1ky/talkify/internal/routers"
 	"github.com/squ1ky/talkify/internal/services"
+	"github.com/squ1ky/talkify/internal/websocket"
 	"log"
+	"net/http"
 	"os"
 )
 
@@ -37,10 +40,63 @@ func main() {
 
 	userRepo := database.NewUserRepository(db)
 	messageRepo := database.NewMessageRepository(db)
+	conversationRepo := database.NewConversationRepository(db)
+	outboxRepo := database.NewOutboxRepository(db)
+	clusterRepo := database.NewClusterRepository(db)
+	refreshTokenRepo := database.NewRefreshTokenRepository(db)
+	revocationRepo := database.NewRevocationRepository(db)
+	ticketRepo := database.NewTicketRepository(db)
+	presenceRepo := database.NewPresenceRepository(db)
+	reactionRepo := database.NewReactionRepository(db)
+
+	eventPublisher := services.NewEventPublisher(cfg.Kafka)
+	defer eventPublisher.Close()
+
+	outboxRelay := services.NewOutboxRelay(outboxRepo, eventPublisher, cfg.Outbox)
+	go outboxRelay.Run()
+	defer outboxRelay.Stop()
+
 	userService := services.NewUserService(userRepo)
-	messageService := services.NewMessageService(messageRepo, userRepo)
+	messageService := services.NewMessageService(messageRepo, userRepo, conversationRepo, reactionRepo)
+	conversationService := services.NewConversationService(conversationRepo, messageRepo, userRepo, reactionRepo)
+	presenceService := services.NewPresenceService(presenceRepo)
+	keySet, err := services.LoadKeySet(cfg.JWT)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	jwtService := services.NewJWTService(keySet, cfg.JWT)
+	authService := services.NewAuthService(jwtService, refreshTokenRepo, revocationRepo, ticketRepo, userRepo, cfg.JWT.RefreshExpiresIn)
+
+	var clusterBus services.ClusterBus
+	if cfg.Cluster.Enabled {
+		postgresCluster, err := services.NewPostgresCluster(clusterRepo, cfg.Database.GetDSN(), cfg.Cluster)
+		if err != nil {
+			log.Fatalf("Failed to join cluster: %v", err)
+		}
+		defer postgresCluster.Close()
+		clusterBus = postgresCluster
+	}
+
+	hub := websocket.NewHub(messageService, presenceService, clusterBus, cfg.WebSocket)
+	go hub.Run()
+	expvar.Publish("ws_send_queues", expvar.Func(func() interface{} {
+		return hub.SendQueueStats()
+	}))
+	go hub.ListenCluster()
+
+	if eventConsumer := services.NewEventConsumer(cfg.Kafka); eventConsumer != nil {
+		go hub.ConsumeEvents(eventConsumer)
+		defer eventConsumer.Close()
+	}
+
+	go func() {
+		if err := http.ListenAndServe(cfg.Server.AdminAddress, routers.SetupAdminRouter()); err != nil {
+			log.Printf("Admin server stopped: %v", err)
+		}
+	}()
 
-	r := routers.SetupRouter(cfg.JWT.Secret, userService, messageService)
+	r := routers.SetupRouter(cfg, userService, messageService, conversationService, presenceService, authService, keySet, hub)
 
 	r.Run(cfg.Server.GetServerAddress())
 }
@@ -2,25 +2,35 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // Config contains all application settings
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Kafka    KafkaConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Kafka     KafkaConfig
+	Outbox    OutboxConfig
+	WebSocket WebSocketConfig
+	Cluster   ClusterConfig
 }
 
 // ServerConfig defines settings for HTTP server
+// AllowedOrigins lists browser origins allowed to call the API and open WebSockets,
+// "https://*.example.com" matches any subdomain and "*" any origin; same-origin requests are always allowed
+// AdminAddress is the internal listener for metrics, it must not be reachable from the internet
 type ServerConfig struct {
-	Host         string
-	Port         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
+	Host           string
+	Port           string
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	AllowedOrigins []string
+	AdminAddress   string
 }
 
 // DatabaseConfig defines settings for database
@@ -34,15 +44,55 @@ type DatabaseConfig struct {
 }
 
 // JWTConfig defines settings for JWT tokens
+// Tokens are signed with ActiveKeyID, other keys only verify tokens issued before rotation
 type JWTConfig struct {
-	Secret    string
-	ExpiresIn time.Duration
+	Secret           string
+	SecretKeyID      string
+	KeysDir          string
+	ActiveKeyID      string
+	Issuer           string
+	Audience         string
+	Leeway           time.Duration
+	ExpiresIn        time.Duration
+	RefreshExpiresIn time.Duration
 }
 
 // KafkaConfig defines settings for Kafka
 type KafkaConfig struct {
-	Brokers []string
-	Topic   string
+	Enabled       bool
+	Brokers       []string
+	Topic         string
+	ConsumerGroup string
+}
+
+// OutboxConfig defines settings for the outbox relay worker
+// Retention is how long published and failed entries are kept before they are pruned
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	Retention    time.Duration
+}
+
+// WebSocketConfig defines settings for WebSocket connections
+// AllowFrameAuth lets clients without credentials on upgrade authenticate with the first frame
+type WebSocketConfig struct {
+	PingInterval   time.Duration
+	PongWait       time.Duration
+	WriteWait      time.Duration
+	MaxMessageSize int64
+	SendBufferSize int
+	AllowFrameAuth bool
+	AuthTimeout    time.Duration
+}
+
+// ClusterConfig defines settings for fan-out between instances over Postgres LISTEN/NOTIFY
+type ClusterConfig struct {
+	Enabled           bool
+	NodeID            string
+	Channel           string
+	HeartbeatInterval time.Duration
+	NodeTTL           time.Duration
 }
 
 // Load sets up configuration with env variables
@@ -53,6 +103,9 @@ func Load() (*Config, error) {
 			Port:         getEnv("SERVER_PORT", "8080"),
 			ReadTimeout:  parseDuration(getEnv("SERVER_READ_TIMEOUT", "10s")),
 			WriteTimeout: parseDuration(getEnv("SERVER_WRITE_TIMEOUT", "10s")),
+
+			AllowedOrigins: parseStringSlice(getEnv("SERVER_ALLOWED_ORIGINS", "")),
+			AdminAddress:   getEnv("SERVER_ADMIN_ADDRESS", "localhost:6060"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -63,12 +116,45 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:    getEnv("JWT_SECRET", ""),
-			ExpiresIn: parseDuration(getEnv("JWT_EXPIRES_IN", "24h")),
+			Secret:           getEnv("JWT_SECRET", ""),
+			SecretKeyID:      getEnv("JWT_SECRET_KEY_ID", "hs256"),
+			KeysDir:          getEnv("JWT_KEYS_DIR", ""),
+			ActiveKeyID:      getEnv("JWT_ACTIVE_KEY_ID", ""),
+			Issuer:           getEnv("JWT_ISSUER", "talkify"),
+			Audience:         getEnv("JWT_AUDIENCE", "talkify-api"),
+			Leeway:           parseDuration(getEnv("JWT_LEEWAY", "30s")),
+			ExpiresIn:        parseDuration(getEnv("JWT_EXPIRES_IN", "15m")),
+			RefreshExpiresIn: parseDuration(getEnv("JWT_REFRESH_EXPIRES_IN", "720h")),
 		},
 		Kafka: KafkaConfig{
+			Enabled: parseBool(getEnv("KAFKA_ENABLED", "false")),
 			Brokers: parseStringSlice(getEnv("KAFKA_BROKERS", "localhost:9092")),
 			Topic:   getEnv("KAFKA_TOPIC", "talkify-messages"),
+			// Must be unique per instance, defaults to talkify-<hostname>
+			ConsumerGroup: getEnv("KAFKA_CONSUMER_GROUP", ""),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: parseDuration(getEnv("OUTBOX_POLL_INTERVAL", "1s")),
+			BatchSize:    int(parseInt64(getEnv("OUTBOX_BATCH_SIZE", "100"), 100)),
+			MaxAttempts:  int(parseInt64(getEnv("OUTBOX_MAX_ATTEMPTS", "10"), 10)),
+			Retention:    parseDuration(getEnv("OUTBOX_RETENTION", "168h")),
+		},
+		WebSocket: WebSocketConfig{
+			PingInterval:   parseDuration(getEnv("WS_PING_INTERVAL", "54s")),
+			PongWait:       parseDuration(getEnv("WS_PONG_WAIT", "60s")),
+			WriteWait:      parseDuration(getEnv("WS_WRITE_WAIT", "10s")),
+			MaxMessageSize: parseInt64(getEnv("WS_MAX_MESSAGE_SIZE", "4096"), 4096),
+			SendBufferSize: int(parseInt64(getEnv("WS_SEND_BUFFER_SIZE", "256"), 256)),
+			AllowFrameAuth: parseBool(getEnv("WS_ALLOW_FRAME_AUTH", "false")),
+			AuthTimeout:    parseDuration(getEnv("WS_AUTH_TIMEOUT", "10s")),
+		},
+		Cluster: ClusterConfig{
+			Enabled: parseBool(getEnv("CLUSTER_ENABLED", "false")),
+			// Must be unique per instance, defaults to <hostname>-<pid>
+			NodeID:            getEnv("CLUSTER_NODE_ID", ""),
+			Channel:           getEnv("CLUSTER_CHANNEL", "talkify_cluster"),
+			HeartbeatInterval: parseDuration(getEnv("CLUSTER_HEARTBEAT_INTERVAL", "5s")),
+			NodeTTL:           parseDuration(getEnv("CLUSTER_NODE_TTL", "15s")),
 		},
 	}
 
@@ -81,21 +167,77 @@ func Load() (*Config, error) {
 
 // validate checks required configuration parameters
 func (c *Config) validate() error {
+	for _, origin := range c.Server.AllowedOrigins {
+		if !isValidOrigin(origin) {
+			return fmt.Errorf("SERVER_ALLOWED_ORIGINS: invalid origin %q", origin)
+		}
+	}
+
 	if c.Database.Password == "" {
 		return fmt.Errorf("DB_PASSWORD is required")
 	}
 
-	if c.JWT.Secret == "" {
-		return fmt.Errorf("JWT_SECRET is required")
+	if c.JWT.Secret == "" && c.JWT.KeysDir == "" {
+		return fmt.Errorf("JWT_SECRET or JWT_KEYS_DIR is required")
 	}
 
-	if len(c.JWT.Secret) < 32 {
+	if c.JWT.Secret != "" && len(c.JWT.Secret) < 32 {
 		return fmt.Errorf("JWT_SECRET must be at least 32 characters")
 	}
 
+	if c.JWT.Secret == "" && c.JWT.ActiveKeyID == "" {
+		return fmt.Errorf("JWT_ACTIVE_KEY_ID is required when JWT_SECRET is not set")
+	}
+
+	if c.JWT.ExpiresIn >= c.JWT.RefreshExpiresIn {
+		return fmt.Errorf("JWT_EXPIRES_IN must be less than JWT_REFRESH_EXPIRES_IN")
+	}
+
+	if c.Kafka.Enabled && (len(c.Kafka.Brokers) == 0 || c.Kafka.Topic == "") {
+		return fmt.Errorf("KAFKA_BROKERS and KAFKA_TOPIC are required when Kafka is enabled")
+	}
+
+	if c.Outbox.BatchSize <= 0 || c.Outbox.MaxAttempts <= 0 {
+		return fmt.Errorf("OUTBOX_BATCH_SIZE and OUTBOX_MAX_ATTEMPTS must be positive")
+	}
+
+	if c.Outbox.Retention <= 0 {
+		return fmt.Errorf("OUTBOX_RETENTION must be positive")
+	}
+
+	if c.WebSocket.PingInterval >= c.WebSocket.PongWait {
+		return fmt.Errorf("WS_PING_INTERVAL must be less than WS_PONG_WAIT")
+	}
+
+	if c.WebSocket.MaxMessageSize <= 0 {
+		return fmt.Errorf("WS_MAX_MESSAGE_SIZE must be positive")
+	}
+
+	if c.WebSocket.SendBufferSize <= 0 {
+		return fmt.Errorf("WS_SEND_BUFFER_SIZE must be positive")
+	}
+
+	if c.Cluster.Enabled && c.Cluster.HeartbeatInterval >= c.Cluster.NodeTTL {
+		return fmt.Errorf("CLUSTER_HEARTBEAT_INTERVAL must be less than CLUSTER_NODE_TTL")
+	}
+
 	return nil
 }
 
+// isValidOrigin checks that origin is "*" or "scheme://host[:port]" without path
+func isValidOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "" && u.RawQuery == ""
+}
+
 // getEnv retrieves value of env variable or returns default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -114,6 +256,24 @@ func parseDuration(s string) time.Duration {
 	return duration
 }
 
+// parseBool parses string in bool, returns false on error
+func parseBool(s string) bool {
+	value, err := strconv.ParseBool(s)
+	if err != nil {
+		return false
+	}
+	return value
+}
+
+// parseInt64 parses string in int64, returns default value on error
+func parseInt64(s string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 // parseStringSlice parses string with splitter into slice of strings
 func parseStringSlice(s string) []string {
 	if s == "" {
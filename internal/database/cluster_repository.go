@@ -0,0 +1,161 @@
+package database
+
+import (
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+// ClusterRepository handles database operations for cluster membership and presence
+// Timestamps come from the database clock, so nodes with skewed clocks agree on liveness
+type ClusterRepository struct {
+	db *DB
+}
+
+// NewClusterRepository creates a new cluster repository
+func NewClusterRepository(db *DB) *ClusterRepository {
+	return &ClusterRepository{db: db}
+}
+
+// Heartbeat registers node or refreshes its liveness
+// Returns true if node was registered anew, e.g. after another node removed it as stale
+func (cl *ClusterRepository) Heartbeat(nodeID string) (bool, error) {
+	query := `
+		INSERT INTO cluster_nodes (node_id, heartbeat_at)
+		VALUES ($1, NOW())
+		ON CONFLICT (node_id) DO UPDATE SET heartbeat_at = NOW()
+		RETURNING xmax = 0`
+
+	var inserted bool
+	if err := cl.db.QueryRow(query, nodeID).Scan(&inserted); err != nil {
+		return false, fmt.Errorf("failed to update node heartbeat: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// RemoveNode removes node with all its presence entries
+func (cl *ClusterRepository) RemoveNode(nodeID string) error {
+	query := `DELETE FROM cluster_nodes WHERE node_id = $1`
+
+	if _, err := cl.db.Exec(query, nodeID); err != nil {
+		return fmt.Errorf("failed to remove cluster node: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveStaleNodes removes nodes without heartbeat for longer than ttl, e.g. crashed ones
+func (cl *ClusterRepository) RemoveStaleNodes(ttl time.Duration) (int64, error) {
+	query := `DELETE FROM cluster_nodes WHERE heartbeat_at < NOW() - make_interval(secs => $1)`
+
+	result, err := cl.db.Exec(query, ttl.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove stale cluster nodes: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// SetOnline records that user has connections on the node
+func (cl *ClusterRepository) SetOnline(nodeID string, userID int) error {
+	query := `
+		INSERT INTO cluster_presence (node_id, user_id, connected_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (node_id, user_id) DO NOTHING`
+
+	if _, err := cl.db.Exec(query, nodeID, userID); err != nil {
+		return fmt.Errorf("failed to set user online: %w", err)
+	}
+
+	return nil
+}
+
+// SetOnlineMany records that users have connections on the node
+func (cl *ClusterRepository) SetOnlineMany(nodeID string, userIDs []int) error {
+	query := `
+		INSERT INTO cluster_presence (node_id, user_id, connected_at)
+		SELECT $1, user_id, NOW()
+		FROM unnest($2::int[]) AS user_id
+		ON CONFLICT (node_id, user_id) DO NOTHING`
+
+	if _, err := cl.db.Exec(query, nodeID, pq.Array(userIDs)); err != nil {
+		return fmt.Errorf("failed to set users online: %w", err)
+	}
+
+	return nil
+}
+
+// SetOffline records that user has no connections left on the node
+func (cl *ClusterRepository) SetOffline(nodeID string, userID int) error {
+	query := `DELETE FROM cluster_presence WHERE node_id = $1 AND user_id = $2`
+
+	if _, err := cl.db.Exec(query, nodeID, userID); err != nil {
+		return fmt.Errorf("failed to set user offline: %w", err)
+	}
+
+	return nil
+}
+
+// GetOnlineUserIDs returns users connected to any node with heartbeat within ttl
+func (cl *ClusterRepository) GetOnlineUserIDs(ttl time.Duration) ([]int, error) {
+	query := `
+		SELECT DISTINCT p.user_id
+		FROM cluster_presence p
+		INNER JOIN cluster_nodes n ON n.node_id = p.node_id
+		WHERE n.heartbeat_at >= NOW() - make_interval(secs => $1)
+		ORDER BY p.user_id`
+
+	rows, err := cl.db.Query(query, ttl.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get online users: %w", err)
+	}
+	defer rows.Close()
+
+	userIDs := []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan online user ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating online users: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// IsUserOnline checks if user is connected to any node with heartbeat within ttl
+func (cl *ClusterRepository) IsUserOnline(userID int, ttl time.Duration) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM cluster_presence p
+			INNER JOIN cluster_nodes n ON n.node_id = p.node_id
+			WHERE p.user_id = $1 AND n.heartbeat_at >= NOW() - make_interval(secs => $2)
+		)`
+
+	var online bool
+	if err := cl.db.QueryRow(query, userID, ttl.Seconds()).Scan(&online); err != nil {
+		return false, fmt.Errorf("failed to check user presence: %w", err)
+	}
+
+	return online, nil
+}
+
+// Notify sends payload to all listeners of the channel
+func (cl *ClusterRepository) Notify(channel, payload string) error {
+	if _, err := cl.db.Exec(`SELECT pg_notify($1, $2)`, channel, payload); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,412 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/squ1ky/talkify/internal/models"
+	"time"
+)
+
+// ConversationRepository handles database operations for group conversations
+type ConversationRepository struct {
+	db *DB
+}
+
+// NewConversationRepository creates a new conversation repository
+func NewConversationRepository(db *DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// Create creates a new conversation with its creator as owner and given users as members
+func (cr *ConversationRepository) Create(conversation *models.Conversation, memberIDs []int) error {
+	tx, err := cr.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO conversations (name, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	err = tx.QueryRow(
+		query,
+		conversation.Name,
+		conversation.CreatedBy,
+		conversation.CreatedAt,
+		conversation.UpdatedAt,
+	).Scan(&conversation.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create conversation: %w", err)
+	}
+
+	memberQuery := `
+		INSERT INTO conversation_members (conversation_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (conversation_id, user_id) DO NOTHING`
+
+	if _, err := tx.Exec(memberQuery, conversation.ID, conversation.CreatedBy, models.RoleOwner, conversation.CreatedAt); err != nil {
+		return fmt.Errorf("failed to add conversation owner: %w", err)
+	}
+
+	for _, userID := range memberIDs {
+		if _, err := tx.Exec(memberQuery, conversation.ID, userID, models.RoleMember, conversation.CreatedAt); err != nil {
+			return fmt.Errorf("failed to add conversation member: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit conversation: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a conversation by ID
+func (cr *ConversationRepository) GetByID(id int) (*models.Conversation, error) {
+	conversation := &models.Conversation{}
+	query := `
+		SELECT id, name, COALESCE(created_by, 0), created_at, updated_at
+		FROM conversations
+		WHERE id = $1`
+
+	err := cr.db.QueryRow(query, id).Scan(
+		&conversation.ID,
+		&conversation.Name,
+		&conversation.CreatedBy,
+		&conversation.CreatedAt,
+		&conversation.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("conversation not found")
+		}
+		return nil, fmt.Errorf("failed to get conversation by ID: %w", err)
+	}
+
+	return conversation, nil
+}
+
+// ListByUser returns conversations the user is a member of
+func (cr *ConversationRepository) ListByUser(userID int) ([]models.ConversationResponse, error) {
+	query := `
+		SELECT c.id, c.name, COALESCE(c.created_by, 0), c.created_at, c.updated_at
+		FROM conversations c
+		INNER JOIN conversation_members cm ON cm.conversation_id = c.id
+		WHERE cm.user_id = $1
+		ORDER BY c.updated_at DESC`
+
+	rows, err := cr.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var conversations []models.ConversationResponse
+	for rows.Next() {
+		var conversation models.ConversationResponse
+		err := rows.Scan(
+			&conversation.ID,
+			&conversation.Name,
+			&conversation.CreatedBy,
+			&conversation.CreatedAt,
+			&conversation.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation row: %w", err)
+		}
+		conversations = append(conversations, conversation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversation rows: %w", err)
+	}
+
+	return conversations, nil
+}
+
+// UpdateName renames a conversation
+func (cr *ConversationRepository) UpdateName(id int, name string) error {
+	query := `UPDATE conversations SET name = $1, updated_at = $2 WHERE id = $3`
+
+	result, err := cr.db.Exec(query, name, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("conversation not found")
+	}
+
+	return nil
+}
+
+// Delete removes a conversation with all its members and messages
+func (cr *ConversationRepository) Delete(id int) error {
+	query := `DELETE FROM conversations WHERE id = $1`
+
+	result, err := cr.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("conversation not found")
+	}
+
+	return nil
+}
+
+// GetMember retrieves user's membership in a conversation
+func (cr *ConversationRepository) GetMember(conversationID, userID int) (*models.ConversationMember, error) {
+	member := &models.ConversationMember{}
+	query := `
+		SELECT conversation_id, user_id, role, joined_at
+		FROM conversation_members
+		WHERE conversation_id = $1 AND user_id = $2`
+
+	err := cr.db.QueryRow(query, conversationID, userID).Scan(
+		&member.ConversationID,
+		&member.UserID,
+		&member.Role,
+		&member.JoinedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("member not found")
+		}
+		return nil, fmt.Errorf("failed to get conversation member: %w", err)
+	}
+
+	return member, nil
+}
+
+// GetMembers returns all members of a conversation with user info
+func (cr *ConversationRepository) GetMembers(conversationID int) ([]models.ConversationMemberResponse, error) {
+	query := `
+		SELECT u.id, u.username, u.created_at, cm.role, cm.joined_at
+		FROM conversation_members cm
+		INNER JOIN users u ON cm.user_id = u.id
+		WHERE cm.conversation_id = $1
+		ORDER BY cm.joined_at ASC, u.id ASC`
+
+	rows, err := cr.db.Query(query, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []models.ConversationMemberResponse
+	for rows.Next() {
+		var member models.ConversationMemberResponse
+		err := rows.Scan(
+			&member.User.ID,
+			&member.User.Username,
+			&member.User.CreatedAt,
+			&member.Role,
+			&member.JoinedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan member row: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating member rows: %w", err)
+	}
+
+	return members, nil
+}
+
+// GetMemberIDs returns IDs of all members of a conversation
+func (cr *ConversationRepository) GetMemberIDs(conversationID int) ([]int, error) {
+	query := `SELECT user_id FROM conversation_members WHERE conversation_id = $1`
+
+	rows, err := cr.db.Query(query, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation member IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan member ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating member IDs: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// AddMember adds user to a conversation with given role
+func (cr *ConversationRepository) AddMember(conversationID, userID int, role string) error {
+	query := `
+		INSERT INTO conversation_members (conversation_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, $4)`
+
+	if _, err := cr.db.Exec(query, conversationID, userID, role, time.Now()); err != nil {
+		return fmt.Errorf("failed to add conversation member: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateMemberRole changes member's role in a conversation
+func (cr *ConversationRepository) UpdateMemberRole(conversationID, userID int, role string) error {
+	query := `UPDATE conversation_members SET role = $1 WHERE conversation_id = $2 AND user_id = $3`
+
+	result, err := cr.db.Exec(query, role, conversationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update member role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	return nil
+}
+
+// RemoveMember removes user from a conversation
+func (cr *ConversationRepository) RemoveMember(conversationID, userID int) error {
+	query := `DELETE FROM conversation_members WHERE conversation_id = $1 AND user_id = $2`
+
+	result, err := cr.db.Exec(query, conversationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove conversation member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	return nil
+}
+
+// Leave removes user from a conversation, passing ownership on if the owner leaves
+// Members are locked, so a concurrent leave or removal can't leave the conversation without an owner
+// The conversation is deleted with its last member; returns false when user is not a member
+func (cr *ConversationRepository) Leave(conversationID, userID int) (bool, error) {
+	tx, err := cr.db.BeginTx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	members, err := lockMembers(tx, conversationID)
+	if err != nil {
+		return false, err
+	}
+
+	role := ""
+	for _, member := range members {
+		if member.UserID == userID {
+			role = member.Role
+			break
+		}
+	}
+	if role == "" {
+		return false, nil
+	}
+
+	if role == models.RoleOwner {
+		successorID := models.NextOwner(members, userID)
+		if successorID == 0 {
+			if _, err := tx.Exec(`DELETE FROM conversations WHERE id = $1`, conversationID); err != nil {
+				return false, fmt.Errorf("failed to delete conversation: %w", err)
+			}
+			if err := tx.Commit(); err != nil {
+				return false, fmt.Errorf("failed to commit leaving conversation: %w", err)
+			}
+			return true, nil
+		}
+
+		promoteQuery := `UPDATE conversation_members SET role = $1 WHERE conversation_id = $2 AND user_id = $3`
+		if _, err := tx.Exec(promoteQuery, models.RoleOwner, conversationID, successorID); err != nil {
+			return false, fmt.Errorf("failed to transfer ownership: %w", err)
+		}
+	}
+
+	removeQuery := `DELETE FROM conversation_members WHERE conversation_id = $1 AND user_id = $2`
+	if _, err := tx.Exec(removeQuery, conversationID, userID); err != nil {
+		return false, fmt.Errorf("failed to remove conversation member: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit leaving conversation: %w", err)
+	}
+
+	return true, nil
+}
+
+// lockMembers returns members of a conversation ordered by join time, locking their rows until tx ends
+func lockMembers(tx *sql.Tx, conversationID int) ([]models.ConversationMember, error) {
+	query := `
+		SELECT conversation_id, user_id, role, joined_at
+		FROM conversation_members
+		WHERE conversation_id = $1
+		ORDER BY joined_at ASC, user_id ASC
+		FOR UPDATE`
+
+	rows, err := tx.Query(query, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock conversation members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []models.ConversationMember
+	for rows.Next() {
+		var member models.ConversationMember
+		err := rows.Scan(
+			&member.ConversationID,
+			&member.UserID,
+			&member.Role,
+			&member.JoinedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan member row: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating member rows: %w", err)
+	}
+
+	return members, nil
+}
@@ -19,16 +19,25 @@ func NewMessageRepository(db *DB) *MessageRepository {
 }
 
 // Create creates a new message in the database
+// message.sent event is written to the outbox in the same transaction
 func (mr *MessageRepository) Create(message *models.Message) error {
+	tx, err := mr.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO messages (sender_id, receiver_id, content, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO messages (sender_id, receiver_id, conversation_id, reply_to_id, content, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
 
-	err := mr.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		message.SenderID,
-		message.ReceiverID,
+		nullableID(message.ReceiverID),
+		nullableID(message.ConversationID),
+		nullableID(message.ReplyToID),
 		message.Content,
 		message.CreatedAt,
 	).Scan(&message.ID)
@@ -37,6 +46,14 @@ func (mr *MessageRepository) Create(message *models.Message) error {
 		return fmt.Errorf("failed to create message: %w", err)
 	}
 
+	if err := insertOutboxEvent(tx, message.ToKafkaEvent(models.EventTypeMessageSent)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit message: %w", err)
+	}
+
 	return nil
 }
 
@@ -44,7 +61,7 @@ func (mr *MessageRepository) Create(message *models.Message) error {
 func (mr *MessageRepository) GetByID(id int) (*models.Message, error) {
 	message := &models.Message{}
 	query := `
-		SELECT id, sender_id, receiver_id, content, created_at
+		SELECT id, sender_id, COALESCE(receiver_id, 0), COALESCE(conversation_id, 0), COALESCE(reply_to_id, 0), content, created_at, edited_at, deleted_at, delivered_at, read_at
 		FROM messages
 		WHERE id = $1`
 
@@ -52,8 +69,14 @@ func (mr *MessageRepository) GetByID(id int) (*models.Message, error) {
 		&message.ID,
 		&message.SenderID,
 		&message.ReceiverID,
+		&message.ConversationID,
+		&message.ReplyToID,
 		&message.Content,
 		&message.CreatedAt,
+		&message.EditedAt,
+		&message.DeletedAt,
+		&message.DeliveredAt,
+		&message.ReadAt,
 	)
 
 	if err != nil {
@@ -66,19 +89,73 @@ func (mr *MessageRepository) GetByID(id int) (*models.Message, error) {
 	return message, nil
 }
 
-// GetConversationHistory returns history between two users
+// UpdateContent replaces message content and stores the previous version as a revision
+// message.edited event is written to the outbox in the same transaction
+func (mr *MessageRepository) UpdateContent(message *models.Message, content string, editedAt time.Time) error {
+	tx, err := mr.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	revisionQuery := `
+		INSERT INTO message_revisions (message_id, content, created_at)
+		SELECT id, content, $2
+		FROM messages
+		WHERE id = $1`
+
+	result, err := tx.Exec(revisionQuery, message.ID, editedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create message revision: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("message not found")
+	}
+
+	updateQuery := `UPDATE messages SET content = $1, edited_at = $2, updated_at = $2 WHERE id = $3`
+	if _, err := tx.Exec(updateQuery, content, editedAt, message.ID); err != nil {
+		return fmt.Errorf("failed to update message: %w", err)
+	}
+
+	edited := *message
+	edited.Content = content
+	edited.EditedAt = &editedAt
+
+	if err := insertOutboxEvent(tx, edited.ToKafkaEvent(models.EventTypeMessageEdited)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit message update: %w", err)
+	}
+
+	*message = edited
+	return nil
+}
+
+// GetConversationHistory returns history between two users as seen by userID1
 func (mr *MessageRepository) GetConversationHistory(userID1, userID2 int, limit, offset int) ([]models.MessageWithUserResponse, error) {
 	query := `
 		SELECT
-			m.id, m.content, m.created_at,
+			m.id, CASE WHEN m.deleted_at IS NULL THEN m.content ELSE '' END, m.created_at, m.edited_at, m.deleted_at, m.delivered_at, m.read_at,
 			s.id as sender_id, s.username as sender_username, s.created_at as sender_created_at,
-			r.id as receiver_id, r.username as receiver_username, r.created_at as receiver_created_at
+			r.id as receiver_id, r.username as receiver_username, r.created_at as receiver_created_at,
+			COALESCE(m.reply_to_id, 0), q.id, CASE WHEN q.deleted_at IS NULL THEN q.content ELSE '' END, q.sender_id, qs.username, q.created_at, q.deleted_at IS NOT NULL
 		FROM messages m
 		INNER JOIN users s ON m.sender_id = s.id
 		INNER JOIN users r ON m.receiver_id = r.id
+		LEFT JOIN messages q ON q.id = m.reply_to_id
+		LEFT JOIN users qs ON qs.id = q.sender_id
 		WHERE
-			(m.sender_id = $1 AND m.receiver_id = $2) OR
-			(m.sender_id = $2 AND m.receiver_id = $1)
+			((m.sender_id = $1 AND m.receiver_id = $2) OR
+			(m.sender_id = $2 AND m.receiver_id = $1)) AND
+			NOT EXISTS (SELECT 1 FROM message_hides h WHERE h.message_id = m.id AND h.user_id = $1)
 		ORDER BY m.created_at DESC
 		LIMIT $3 OFFSET $4`
 
@@ -91,13 +168,15 @@ func (mr *MessageRepository) GetConversationHistory(userID1, userID2 int, limit,
 	var messages []models.MessageWithUserResponse
 	for rows.Next() {
 		var msg models.MessageWithUserResponse
+		var reply replyPreview
 		var sender models.UserResponse
 		var receiver models.UserResponse
 
 		err := rows.Scan(
-			&msg.ID, &msg.Content, &msg.CreatedAt,
+			&msg.ID, &msg.Content, &msg.CreatedAt, &msg.EditedAt, &msg.DeletedAt, &msg.DeliveredAt, &msg.ReadAt,
 			&sender.ID, &sender.Username, &sender.CreatedAt,
 			&receiver.ID, &receiver.Username, &receiver.CreatedAt,
+			&msg.ReplyToID, &reply.id, &reply.content, &reply.senderID, &reply.senderUsername, &reply.createdAt, &reply.deleted,
 		)
 
 		if err != nil {
@@ -105,7 +184,9 @@ func (mr *MessageRepository) GetConversationHistory(userID1, userID2 int, limit,
 		}
 
 		msg.Sender = sender
-		msg.Receiver = receiver
+		msg.Receiver = &receiver
+		msg.Status = models.MessageStatus(msg.DeliveredAt, msg.ReadAt)
+		msg.ReplyTo = reply.toPreview()
 		messages = append(messages, msg)
 	}
 
@@ -116,17 +197,88 @@ func (mr *MessageRepository) GetConversationHistory(userID1, userID2 int, limit,
 	return messages, nil
 }
 
+// GetGroupHistory returns history of a group conversation as seen by viewerID
+func (mr *MessageRepository) GetGroupHistory(conversationID, viewerID int, limit, offset int) ([]models.MessageWithUserResponse, error) {
+	query := `
+		SELECT
+			m.id, m.conversation_id, CASE WHEN m.deleted_at IS NULL THEN m.content ELSE '' END, m.created_at, m.edited_at, m.deleted_at, m.delivered_at, m.read_at,
+			s.id as sender_id, s.username as sender_username, s.created_at as sender_created_at,
+			COALESCE(m.reply_to_id, 0), q.id, CASE WHEN q.deleted_at IS NULL THEN q.content ELSE '' END, q.sender_id, qs.username, q.created_at, q.deleted_at IS NOT NULL
+		FROM messages m
+		INNER JOIN users s ON m.sender_id = s.id
+		LEFT JOIN messages q ON q.id = m.reply_to_id
+		LEFT JOIN users qs ON qs.id = q.sender_id
+		WHERE m.conversation_id = $1 AND
+			NOT EXISTS (SELECT 1 FROM message_hides h WHERE h.message_id = m.id AND h.user_id = $2)
+		ORDER BY m.created_at DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := mr.db.Query(query, conversationID, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group history: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []models.MessageWithUserResponse
+	for rows.Next() {
+		var msg models.MessageWithUserResponse
+		var reply replyPreview
+
+		err := rows.Scan(
+			&msg.ID, &msg.ConversationID, &msg.Content, &msg.CreatedAt, &msg.EditedAt, &msg.DeletedAt, &msg.DeliveredAt, &msg.ReadAt,
+			&msg.Sender.ID, &msg.Sender.Username, &msg.Sender.CreatedAt,
+			&msg.ReplyToID, &reply.id, &reply.content, &reply.senderID, &reply.senderUsername, &reply.createdAt, &reply.deleted,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message row: %w", err)
+		}
+
+		msg.Status = models.MessageStatus(msg.DeliveredAt, msg.ReadAt)
+		msg.ReplyTo = reply.toPreview()
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message rows: %w", err)
+	}
+
+	return messages, nil
+}
+
+// CountGroupMessages returns total number of messages in a group conversation visible to viewerID
+func (mr *MessageRepository) CountGroupMessages(conversationID, viewerID int) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*)
+		FROM messages m
+		WHERE m.conversation_id = $1 AND
+			NOT EXISTS (SELECT 1 FROM message_hides h WHERE h.message_id = m.id AND h.user_id = $2)`
+
+	err := mr.db.QueryRow(query, conversationID, viewerID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count group messages: %w", err)
+	}
+
+	return count, nil
+}
+
 // GetUserMessages returns all messages for a specific user (sent and received)
 func (mr *MessageRepository) GetUserMessages(userID int, limit, offset int) ([]models.MessageWithUserResponse, error) {
 	query := `
 		SELECT
-			m.id, m.content, m.created_at,
+			m.id, CASE WHEN m.deleted_at IS NULL THEN m.content ELSE '' END, m.created_at, m.edited_at, m.deleted_at, m.delivered_at, m.read_at,
 			s.id as sender_id, s.username as sender_username, s.created_at as sender_created_at,
-			r.id as receiver_id, r.username as receiver_username, r.created_at as receiver_created_at
+			r.id as receiver_id, r.username as receiver_username, r.created_at as receiver_created_at,
+			COALESCE(m.reply_to_id, 0), q.id, CASE WHEN q.deleted_at IS NULL THEN q.content ELSE '' END, q.sender_id, qs.username, q.created_at, q.deleted_at IS NOT NULL
 		FROM messages m
 		INNER JOIN users s on m.sender_id = s.id
 		INNER JOIN users r on m.receiver_id = r.id
-		WHERE m.sender_id = $1 OR m.receiver_id = $1
+		LEFT JOIN messages q ON q.id = m.reply_to_id
+		LEFT JOIN users qs ON qs.id = q.sender_id
+		WHERE
+			(m.sender_id = $1 OR m.receiver_id = $1) AND
+			NOT EXISTS (SELECT 1 FROM message_hides h WHERE h.message_id = m.id AND h.user_id = $1)
 		ORDER BY m.created_at DESC
 		LIMIT $2 OFFSET $3`
 
@@ -139,13 +291,15 @@ func (mr *MessageRepository) GetUserMessages(userID int, limit, offset int) ([]m
 	var messages []models.MessageWithUserResponse
 	for rows.Next() {
 		var msg models.MessageWithUserResponse
+		var reply replyPreview
 		var sender models.UserResponse
 		var receiver models.UserResponse
 
 		err := rows.Scan(
-			&msg.ID, &msg.Content, &msg.CreatedAt,
+			&msg.ID, &msg.Content, &msg.CreatedAt, &msg.EditedAt, &msg.DeletedAt, &msg.DeliveredAt, &msg.ReadAt,
 			&sender.ID, &sender.Username, &sender.CreatedAt,
 			&receiver.ID, &receiver.Username, &receiver.CreatedAt,
+			&msg.ReplyToID, &reply.id, &reply.content, &reply.senderID, &reply.senderUsername, &reply.createdAt, &reply.deleted,
 		)
 
 		if err != nil {
@@ -153,7 +307,9 @@ func (mr *MessageRepository) GetUserMessages(userID int, limit, offset int) ([]m
 		}
 
 		msg.Sender = sender
-		msg.Receiver = receiver
+		msg.Receiver = &receiver
+		msg.Status = models.MessageStatus(msg.DeliveredAt, msg.ReadAt)
+		msg.ReplyTo = reply.toPreview()
 		messages = append(messages, msg)
 	}
 
@@ -164,22 +320,79 @@ func (mr *MessageRepository) GetUserMessages(userID int, limit, offset int) ([]m
 	return messages, nil
 }
 
-// GetRecentConversations returns list of users with recent conversations
-func (mr *MessageRepository) GetRecentConversations(userID int, limit int) ([]models.UserResponse, error) {
+// GetRecentConversations returns user's direct and group conversations ordered by activity,
+// each with last message preview and number of unread messages
+func (mr *MessageRepository) GetRecentConversations(userID int, limit int) ([]models.ConversationSummaryResponse, error) {
 	query := `
-       WITH recent_conversations AS (
-          SELECT DISTINCT
-             CASE WHEN m.sender_id = $1 THEN m.receiver_id ELSE m.sender_id END as other_user_id,
-             MAX(m.created_at) as last_message_time
-          FROM messages m
-          WHERE m.sender_id = $1 OR m.receiver_id = $1
-          GROUP BY other_user_id
-       )
-       SELECT u.id, u.username, u.created_at
-       FROM recent_conversations rc
-       INNER JOIN users u ON rc.other_user_id = u.id
-       ORDER BY rc.last_message_time DESC
-       LIMIT $2`
+		WITH visible AS (
+			SELECT m.*
+			FROM messages m
+			WHERE
+				(m.sender_id = $1 OR m.receiver_id = $1 OR
+				m.conversation_id IN (SELECT conversation_id FROM conversation_members WHERE user_id = $1)) AND
+				NOT EXISTS (SELECT 1 FROM message_hides h WHERE h.message_id = m.id AND h.user_id = $1)
+		),
+		direct_last AS (
+			SELECT DISTINCT ON (other_user_id) *
+			FROM (
+				SELECT v.*, CASE WHEN v.sender_id = $1 THEN v.receiver_id ELSE v.sender_id END AS other_user_id
+				FROM visible v
+				WHERE (v.sender_id = $1 OR v.receiver_id = $1) AND v.conversation_id IS NULL
+			) d
+			ORDER BY other_user_id, d.created_at DESC, d.id DESC
+		),
+		direct_unread AS (
+			SELECT v.sender_id AS other_user_id, COUNT(*) AS unread
+			FROM visible v
+			WHERE v.receiver_id = $1 AND v.read_at IS NULL AND v.deleted_at IS NULL
+			GROUP BY v.sender_id
+		),
+		group_last AS (
+			SELECT DISTINCT ON (v.conversation_id) v.*
+			FROM visible v
+			INNER JOIN conversation_members cm ON cm.conversation_id = v.conversation_id AND cm.user_id = $1
+			ORDER BY v.conversation_id, v.created_at DESC, v.id DESC
+		),
+		group_unread AS (
+			SELECT v.conversation_id, COUNT(*) AS unread
+			FROM visible v
+			INNER JOIN conversation_members cm ON cm.conversation_id = v.conversation_id AND cm.user_id = $1
+			LEFT JOIN conversation_read_cursors rc ON rc.conversation_id = v.conversation_id AND rc.user_id = $1
+			WHERE v.sender_id <> $1 AND v.deleted_at IS NULL AND v.id > COALESCE(rc.last_read_message_id, 0)
+			GROUP BY v.conversation_id
+		)
+		SELECT * FROM (
+			SELECT
+				'direct' AS type, u.id AS user_id, u.username, u.created_at AS user_created_at,
+				0 AS conversation_id, '' AS name,
+				dl.id AS last_id, dl.content AS last_content, dl.sender_id AS last_sender_id,
+				s.username AS last_sender_username, dl.created_at AS last_created_at,
+				dl.deleted_at IS NOT NULL AS last_deleted,
+				COALESCE(du.unread, 0) AS unread,
+				dl.created_at AS activity_at
+			FROM direct_last dl
+			INNER JOIN users u ON u.id = dl.other_user_id
+			INNER JOIN users s ON s.id = dl.sender_id
+			LEFT JOIN direct_unread du ON du.other_user_id = dl.other_user_id
+
+			UNION ALL
+
+			SELECT
+				'group' AS type, 0, '', NULL,
+				c.id, c.name,
+				gl.id, gl.content, gl.sender_id,
+				s.username, gl.created_at,
+				gl.deleted_at IS NOT NULL,
+				COALESCE(gu.unread, 0),
+				COALESCE(gl.created_at, c.created_at)
+			FROM conversations c
+			INNER JOIN conversation_members cm ON cm.conversation_id = c.id AND cm.user_id = $1
+			LEFT JOIN group_last gl ON gl.conversation_id = c.id
+			LEFT JOIN users s ON s.id = gl.sender_id
+			LEFT JOIN group_unread gu ON gu.conversation_id = c.id
+		) summaries
+		ORDER BY activity_at DESC
+		LIMIT $2`
 
 	rows, err := mr.db.Query(query, userID, limit)
 	if err != nil {
@@ -187,34 +400,85 @@ func (mr *MessageRepository) GetRecentConversations(userID int, limit int) ([]mo
 	}
 	defer rows.Close()
 
-	var users []models.UserResponse
+	var conversations []models.ConversationSummaryResponse
 	for rows.Next() {
+		var summary models.ConversationSummaryResponse
 		var user models.UserResponse
-		err := rows.Scan(&user.ID, &user.Username, &user.CreatedAt)
+		var userCreatedAt, lastCreatedAt, activityAt sql.NullTime
+		var lastID, lastSenderID sql.NullInt64
+		var lastContent, lastSenderUsername sql.NullString
+		var lastDeleted sql.NullBool
+
+		err := rows.Scan(
+			&summary.Type, &user.ID, &user.Username, &userCreatedAt,
+			&summary.ConversationID, &summary.Name,
+			&lastID, &lastContent, &lastSenderID,
+			&lastSenderUsername, &lastCreatedAt,
+			&lastDeleted,
+			&summary.UnreadCount,
+			&activityAt,
+		)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan conversation row: %w", err)
 		}
 
-		users = append(users, user)
+		if summary.Type == models.ConversationTypeDirect {
+			user.CreatedAt = userCreatedAt.Time
+			summary.User = &user
+		}
+
+		if lastID.Valid {
+			preview := &models.MessagePreview{
+				ID:             int(lastID.Int64),
+				SenderID:       int(lastSenderID.Int64),
+				SenderUsername: lastSenderUsername.String,
+				CreatedAt:      lastCreatedAt.Time,
+				Deleted:        lastDeleted.Bool,
+			}
+			if !preview.Deleted {
+				preview.Content = models.TruncatePreview(lastContent.String)
+			}
+			summary.LastMessage = preview
+		}
+
+		conversations = append(conversations, summary)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating conversation rows: %w", err)
 	}
 
-	return users, nil
+	return conversations, nil
 }
 
-// CountConversationMessages returns total number of messages between two users
+// UpdateReadCursor moves user's read position in a group conversation forward
+func (mr *MessageRepository) UpdateReadCursor(userID, conversationID, messageID int) error {
+	query := `
+		INSERT INTO conversation_read_cursors (user_id, conversation_id, last_read_message_id, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, conversation_id) DO UPDATE
+		SET
+			last_read_message_id = GREATEST(conversation_read_cursors.last_read_message_id, EXCLUDED.last_read_message_id),
+			updated_at = EXCLUDED.updated_at`
+
+	if _, err := mr.db.Exec(query, userID, conversationID, messageID, time.Now()); err != nil {
+		return fmt.Errorf("failed to update read cursor: %w", err)
+	}
+
+	return nil
+}
+
+// CountConversationMessages returns total number of messages between two users visible to userID1
 func (mr *MessageRepository) CountConversationMessages(userID1, userID2 int) (int, error) {
 	var count int
 	query := `
 		SELECT COUNT(*)
-		FROM messages
+		FROM messages m
 		WHERE
-			(sender_id = $1 AND receiver_id = $2) OR
-			(sender_id = $2 AND receiver_id = $1)`
+			((m.sender_id = $1 AND m.receiver_id = $2) OR
+			(m.sender_id = $2 AND m.receiver_id = $1)) AND
+			NOT EXISTS (SELECT 1 FROM message_hides h WHERE h.message_id = m.id AND h.user_id = $1)`
 
 	err := mr.db.QueryRow(query, userID1, userID2).Scan(&count)
 	if err != nil {
@@ -224,37 +488,91 @@ func (mr *MessageRepository) CountConversationMessages(userID1, userID2 int) (in
 	return count, nil
 }
 
-// GetMessagesSince returns messages sent after specific time
-func (mr *MessageRepository) GetMessagesSince(userID int, since time.Time) ([]models.MessageWithUserResponse, error) {
+// GetMessagesSince returns direct and group messages of a user sent after specific time
+// and with ID greater than sinceID, oldest first
+// Group messages sent before the user joined are skipped
+func (mr *MessageRepository) GetMessagesSince(userID int, since time.Time, sinceID int, limit int) ([]models.MessageWithUserResponse, error) {
 	query := `
 		SELECT
-			m.id, m.content, m.created_at,
+			m.id, COALESCE(m.conversation_id, 0), CASE WHEN m.deleted_at IS NULL THEN m.content ELSE '' END, m.created_at, m.edited_at, m.deleted_at, m.delivered_at, m.read_at,
 			s.id as sender_id, s.username as sender_username, s.created_at as sender_created_at,
-			r.id as receiver_id, r.username as receiver_username, r.created_at as receiver_created_at
+			r.id as receiver_id, r.username as receiver_username, r.created_at as receiver_created_at,
+			COALESCE(m.reply_to_id, 0), q.id, CASE WHEN q.deleted_at IS NULL THEN q.content ELSE '' END, q.sender_id, qs.username, q.created_at, q.deleted_at IS NOT NULL
 		FROM messages m
 		INNER JOIN users s on m.sender_id = s.id
-		INNER JOIN users r on m.receiver_id = r.id
+		LEFT JOIN users r on m.receiver_id = r.id
+		LEFT JOIN messages q ON q.id = m.reply_to_id
+		LEFT JOIN users qs ON qs.id = q.sender_id
 		WHERE
-			(m.sender_id = $1 OR m.receiver_id = $1) AND
-			m.created_at > $2
-		ORDER BY m.created_at ASC`
-
-	rows, err := mr.db.Query(query, userID, since)
+			(m.sender_id = $1 OR m.receiver_id = $1 OR
+			EXISTS (SELECT 1 FROM conversation_members cm WHERE cm.conversation_id = m.conversation_id AND cm.user_id = $1 AND cm.joined_at <= m.created_at)) AND
+			m.created_at > $2 AND m.id > $3 AND
+			NOT EXISTS (SELECT 1 FROM message_hides h WHERE h.message_id = m.id AND h.user_id = $1)
+		ORDER BY m.created_at ASC, m.id ASC
+		LIMIT $4`
+
+	messages, err := mr.querySyncMessages(query, userID, since, sinceID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get messages since: %w", err)
 	}
+
+	return messages, nil
+}
+
+// GetMessagesUpdatedSince returns messages of a user sent before the sync cursor
+// that were edited, deleted or reacted to after it, least recently updated first
+// The cursor time is since or creation time of message sinceID, whichever is later
+func (mr *MessageRepository) GetMessagesUpdatedSince(userID int, since time.Time, sinceID int, limit int) ([]models.MessageWithUserResponse, error) {
+	query := `
+		SELECT
+			m.id, COALESCE(m.conversation_id, 0), CASE WHEN m.deleted_at IS NULL THEN m.content ELSE '' END, m.created_at, m.edited_at, m.deleted_at, m.delivered_at, m.read_at,
+			s.id as sender_id, s.username as sender_username, s.created_at as sender_created_at,
+			r.id as receiver_id, r.username as receiver_username, r.created_at as receiver_created_at,
+			COALESCE(m.reply_to_id, 0), q.id, CASE WHEN q.deleted_at IS NULL THEN q.content ELSE '' END, q.sender_id, qs.username, q.created_at, q.deleted_at IS NOT NULL
+		FROM messages m
+		INNER JOIN users s on m.sender_id = s.id
+		LEFT JOIN users r on m.receiver_id = r.id
+		LEFT JOIN messages q ON q.id = m.reply_to_id
+		LEFT JOIN users qs ON qs.id = q.sender_id
+		WHERE
+			(m.sender_id = $1 OR m.receiver_id = $1 OR
+			EXISTS (SELECT 1 FROM conversation_members cm WHERE cm.conversation_id = m.conversation_id AND cm.user_id = $1 AND cm.joined_at <= m.created_at)) AND
+			(m.created_at <= $2 OR m.id <= $3) AND
+			m.updated_at > GREATEST($2, COALESCE((SELECT c.created_at FROM messages c WHERE c.id = $3), $2)) AND
+			NOT EXISTS (SELECT 1 FROM message_hides h WHERE h.message_id = m.id AND h.user_id = $1)
+		ORDER BY m.updated_at ASC, m.id ASC
+		LIMIT $4`
+
+	messages, err := mr.querySyncMessages(query, userID, since, sinceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages updated since: %w", err)
+	}
+
+	return messages, nil
+}
+
+// querySyncMessages runs query selecting messages with sender, receiver and reply preview
+func (mr *MessageRepository) querySyncMessages(query string, args ...interface{}) ([]models.MessageWithUserResponse, error) {
+	rows, err := mr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var messages []models.MessageWithUserResponse
 	for rows.Next() {
 		var msg models.MessageWithUserResponse
+		var reply replyPreview
 		var sender models.UserResponse
-		var receiver models.UserResponse
+		var receiverID sql.NullInt64
+		var receiverUsername sql.NullString
+		var receiverCreatedAt sql.NullTime
 
 		err := rows.Scan(
-			&msg.ID, &msg.Content, &msg.CreatedAt,
+			&msg.ID, &msg.ConversationID, &msg.Content, &msg.CreatedAt, &msg.EditedAt, &msg.DeletedAt, &msg.DeliveredAt, &msg.ReadAt,
 			&sender.ID, &sender.Username, &sender.CreatedAt,
-			&receiver.ID, &receiver.Username, &receiver.CreatedAt,
+			&receiverID, &receiverUsername, &receiverCreatedAt,
+			&msg.ReplyToID, &reply.id, &reply.content, &reply.senderID, &reply.senderUsername, &reply.createdAt, &reply.deleted,
 		)
 
 		if err != nil {
@@ -262,7 +580,15 @@ func (mr *MessageRepository) GetMessagesSince(userID int, since time.Time) ([]mo
 		}
 
 		msg.Sender = sender
-		msg.Receiver = receiver
+		if receiverID.Valid {
+			msg.Receiver = &models.UserResponse{
+				ID:        int(receiverID.Int64),
+				Username:  receiverUsername.String,
+				CreatedAt: receiverCreatedAt.Time,
+			}
+		}
+		msg.Status = models.MessageStatus(msg.DeliveredAt, msg.ReadAt)
+		msg.ReplyTo = reply.toPreview()
 		messages = append(messages, msg)
 	}
 
@@ -273,6 +599,144 @@ func (mr *MessageRepository) GetMessagesSince(userID int, since time.Time) ([]mo
 	return messages, nil
 }
 
+// SoftDelete marks message as deleted for everyone, keeping a tombstone row
+// message.deleted event is written to the outbox in the same transaction
+func (mr *MessageRepository) SoftDelete(message *models.Message, deletedAt time.Time) error {
+	tx, err := mr.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE messages SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := tx.Exec(query, deletedAt, message.ID)
+	if err != nil {
+		return fmt.Errorf("failed to soft delete message: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("message not found")
+	}
+
+	deleted := *message
+	deleted.DeletedAt = &deletedAt
+
+	if err := insertOutboxEvent(tx, deleted.ToKafkaEvent(models.EventTypeMessageDeleted)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit message deletion: %w", err)
+	}
+
+	*message = deleted
+	return nil
+}
+
+// Hide hides message from a single user's view
+func (mr *MessageRepository) Hide(messageID, userID int) error {
+	query := `
+		INSERT INTO message_hides (message_id, user_id, hidden_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (message_id, user_id) DO NOTHING`
+
+	if _, err := mr.db.Exec(query, messageID, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to hide message: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDelivered sets delivery time of a message if it was not delivered yet
+// Returns false when message was already delivered
+func (mr *MessageRepository) MarkDelivered(messageID int, deliveredAt time.Time) (bool, error) {
+	query := `UPDATE messages SET delivered_at = $1 WHERE id = $2 AND delivered_at IS NULL`
+
+	result, err := mr.db.Exec(query, deliveredAt, messageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark message delivered: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// MarkDirectRead marks unread messages sent by senderID to readerID up to messageID as read
+// Returns IDs of affected messages
+func (mr *MessageRepository) MarkDirectRead(readerID, senderID, messageID int, readAt time.Time) ([]int, error) {
+	query := `
+		UPDATE messages
+		SET read_at = $1, delivered_at = COALESCE(delivered_at, $1)
+		WHERE
+			receiver_id = $2 AND sender_id = $3 AND
+			id <= $4 AND read_at IS NULL AND deleted_at IS NULL
+		RETURNING id`
+
+	rows, err := mr.db.Query(query, readAt, readerID, senderID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark messages read: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan read message: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating read messages: %w", err)
+	}
+
+	return ids, nil
+}
+
+// MarkGroupRead marks unread messages of a group conversation up to messageID as read,
+// skipping messages sent by the reader; returns IDs of affected messages grouped by sender
+func (mr *MessageRepository) MarkGroupRead(readerID, conversationID, messageID int, readAt time.Time) (map[int][]int, error) {
+	query := `
+		UPDATE messages
+		SET read_at = $1, delivered_at = COALESCE(delivered_at, $1)
+		WHERE
+			conversation_id = $2 AND sender_id <> $3 AND
+			id <= $4 AND read_at IS NULL AND deleted_at IS NULL
+		RETURNING id, sender_id`
+
+	rows, err := mr.db.Query(query, readAt, conversationID, readerID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark messages read: %w", err)
+	}
+	defer rows.Close()
+
+	bySender := make(map[int][]int)
+	for rows.Next() {
+		var id, senderID int
+		if err := rows.Scan(&id, &senderID); err != nil {
+			return nil, fmt.Errorf("failed to scan read message: %w", err)
+		}
+		bySender[senderID] = append(bySender[senderID], id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating read messages: %w", err)
+	}
+
+	return bySender, nil
+}
+
 // Delete removes a message by ID
 func (mr *MessageRepository) Delete(id int) error {
 	query := `DELETE FROM messages WHERE id = $1`
@@ -293,3 +757,34 @@ func (mr *MessageRepository) Delete(id int) error {
 
 	return nil
 }
+
+// replyPreview holds nullable columns of quoted message preview selected with a history row
+type replyPreview struct {
+	id             sql.NullInt64
+	content        sql.NullString
+	senderID       sql.NullInt64
+	senderUsername sql.NullString
+	createdAt      sql.NullTime
+	deleted        sql.NullBool
+}
+
+// toPreview returns preview of quoted message or nil if row is not a reply
+func (p *replyPreview) toPreview() *models.MessagePreview {
+	if !p.id.Valid {
+		return nil
+	}
+
+	return &models.MessagePreview{
+		ID:             int(p.id.Int64),
+		Content:        models.TruncatePreview(p.content.String),
+		SenderID:       int(p.senderID.Int64),
+		SenderUsername: p.senderUsername.String,
+		CreatedAt:      p.createdAt.Time,
+		Deleted:        p.deleted.Bool,
+	}
+}
+
+// nullableID maps zero ID to SQL NULL
+func nullableID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
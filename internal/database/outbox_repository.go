@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/squ1ky/talkify/internal/models"
+	"time"
+)
+
+// outboxLockKey is the advisory lock that lets only one relay drain the outbox at a time
+const outboxLockKey = 7_410_001
+
+// OutboxRepository handles database operations for the transactional outbox
+type OutboxRepository struct {
+	db *DB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// OutboxLock is the outbox advisory lock held on a dedicated connection
+// No transaction is kept open while the lock is held, so events can be published under it
+type OutboxLock struct {
+	conn *sql.Conn
+}
+
+// insertOutboxEvent writes event to the outbox within given transaction
+// Events with already stored idempotency key are ignored
+func insertOutboxEvent(tx *sql.Tx, event models.KafkaMessageEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox event: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox (idempotency_key, event_type, partition_key, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (idempotency_key) DO NOTHING`
+
+	_, err = tx.Exec(query, event.EventID, event.EventType, event.PartitionKey(), payload, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// Lock takes the outbox lock, so that only one relay drains the outbox at a time
+// Returns nil lock if another relay currently holds it
+func (ob *OutboxRepository) Lock() (*OutboxLock, error) {
+	ctx := context.Background()
+
+	conn, err := ob.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, outboxLockKey).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+
+	return &OutboxLock{conn: conn}, nil
+}
+
+// Unlock releases the outbox lock and returns its connection to the pool
+// If the lock can't be released, the connection is discarded, which releases it as well
+func (l *OutboxLock) Unlock() error {
+	_, err := l.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, outboxLockKey)
+	if err != nil {
+		l.conn.Raw(func(interface{}) error {
+			return driver.ErrBadConn
+		})
+		l.conn.Close()
+		return fmt.Errorf("failed to unlock outbox: %w", err)
+	}
+
+	return l.conn.Close()
+}
+
+// GetDue returns up to limit pending entries due for publishing at now, in insertion order
+// Entries queued behind a pending entry of the same partition that waits for its retry are skipped,
+// so events of one conversation are never published out of order
+func (ob *OutboxRepository) GetDue(now time.Time, limit int) ([]models.OutboxEntry, error) {
+	query := `
+		SELECT o.id, o.idempotency_key, o.event_type, o.partition_key, o.payload, o.attempts, o.next_attempt_at, o.created_at
+		FROM outbox o
+		WHERE
+			o.published_at IS NULL AND o.failed_at IS NULL AND o.next_attempt_at <= $1 AND
+			NOT EXISTS (
+				SELECT 1 FROM outbox w
+				WHERE
+					w.partition_key = o.partition_key AND w.id < o.id AND
+					w.published_at IS NULL AND w.failed_at IS NULL AND w.next_attempt_at > $1
+			)
+		ORDER BY o.id ASC
+		LIMIT $2`
+
+	rows, err := ob.db.Query(query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.OutboxEntry
+	for rows.Next() {
+		var entry models.OutboxEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.IdempotencyKey,
+			&entry.EventType,
+			&entry.PartitionKey,
+			&entry.Payload,
+			&entry.Attempts,
+			&entry.NextAttemptAt,
+			&entry.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// MarkPublished marks entries as successfully published
+func (ob *OutboxRepository) MarkPublished(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `UPDATE outbox SET published_at = $1, attempts = attempts + 1, last_error = NULL WHERE id = ANY($2)`
+
+	if _, err := ob.db.Exec(query, time.Now(), pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to mark outbox entries published: %w", err)
+	}
+
+	return nil
+}
+
+// MarkRetry records failed attempt and schedules the next one
+func (ob *OutboxRepository) MarkRetry(id int64, nextAttemptAt time.Time, cause error) error {
+	query := `UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = $3`
+
+	if _, err := ob.db.Exec(query, cause.Error(), nextAttemptAt, id); err != nil {
+		return fmt.Errorf("failed to schedule outbox retry: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed gives up on entry after too many attempts
+func (ob *OutboxRepository) MarkFailed(id int64, cause error) error {
+	query := `UPDATE outbox SET attempts = attempts + 1, last_error = $1, failed_at = $2 WHERE id = $3`
+
+	if _, err := ob.db.Exec(query, cause.Error(), time.Now(), id); err != nil {
+		return fmt.Errorf("failed to mark outbox entry failed: %w", err)
+	}
+
+	return nil
+}
+
+// Prune deletes up to limit published and failed entries processed before olderThan
+// Returns number of deleted entries
+func (ob *OutboxRepository) Prune(olderThan time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM outbox
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE COALESCE(published_at, failed_at) < $1
+			LIMIT $2
+		)`
+
+	result, err := ob.db.Exec(query, olderThan, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune outbox: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
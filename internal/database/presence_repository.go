@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/squ1ky/talkify/internal/models"
+	"time"
+)
+
+// PresenceRepository handles database operations for users' presence
+// Connection state is not stored here, only last_seen_at, away flag and status text
+type PresenceRepository struct {
+	db *DB
+}
+
+// NewPresenceRepository creates a new presence repository
+func NewPresenceRepository(db *DB) *PresenceRepository {
+	return &PresenceRepository{db: db}
+}
+
+// GetByUserID returns stored presence of a user
+func (pr *PresenceRepository) GetByUserID(userID int) (*models.Presence, error) {
+	presence := &models.Presence{}
+	query := `
+		SELECT id, last_seen_at, away, status_text
+		FROM users
+		WHERE id = $1`
+
+	err := pr.scan(pr.db.QueryRow(query, userID), presence)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get presence: %w", err)
+	}
+
+	return presence, nil
+}
+
+// GetByUserIDs returns stored presence of users, unknown IDs are skipped
+func (pr *PresenceRepository) GetByUserIDs(userIDs []int) ([]models.Presence, error) {
+	query := `
+		SELECT id, last_seen_at, away, status_text
+		FROM users
+		WHERE id = ANY($1)
+		ORDER BY id`
+
+	rows, err := pr.db.Query(query, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get presence: %w", err)
+	}
+	defer rows.Close()
+
+	presences := []models.Presence{}
+	for rows.Next() {
+		var presence models.Presence
+		if err := pr.scan(rows, &presence); err != nil {
+			return nil, fmt.Errorf("failed to scan presence row: %w", err)
+		}
+		presences = append(presences, presence)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating presence rows: %w", err)
+	}
+
+	return presences, nil
+}
+
+// Update stores away flag and status text of a user, nil values are left unchanged
+func (pr *PresenceRepository) Update(userID int, away *bool, statusText *string) (*models.Presence, error) {
+	presence := &models.Presence{}
+	query := `
+		UPDATE users
+		SET away = COALESCE($2, away), status_text = COALESCE($3, status_text)
+		WHERE id = $1
+		RETURNING id, last_seen_at, away, status_text`
+
+	err := pr.scan(pr.db.QueryRow(query, userID, away, statusText), presence)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to update presence: %w", err)
+	}
+
+	return presence, nil
+}
+
+// SetLastSeen records when user's last connection was closed
+// Away flag is reset, so user comes back online with the next connection
+func (pr *PresenceRepository) SetLastSeen(userID int, lastSeenAt time.Time) (*models.Presence, error) {
+	presence := &models.Presence{}
+	query := `
+		UPDATE users
+		SET last_seen_at = $2, away = FALSE
+		WHERE id = $1
+		RETURNING id, last_seen_at, away, status_text`
+
+	err := pr.scan(pr.db.QueryRow(query, userID, lastSeenAt), presence)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to set last seen: %w", err)
+	}
+
+	return presence, nil
+}
+
+// GetContactIDs returns users who share a direct chat or a group with the user
+func (pr *PresenceRepository) GetContactIDs(userID int) ([]int, error) {
+	query := `
+		SELECT CASE WHEN sender_id = $1 THEN receiver_id ELSE sender_id END
+		FROM messages
+		WHERE conversation_id IS NULL AND (sender_id = $1 OR receiver_id = $1)
+		UNION
+		SELECT other.user_id
+		FROM conversation_members own
+		INNER JOIN conversation_members other ON other.conversation_id = own.conversation_id
+		WHERE own.user_id = $1`
+
+	rows, err := pr.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contacts: %w", err)
+	}
+	defer rows.Close()
+
+	var contactIDs []int
+	for rows.Next() {
+		var contactID int
+		if err := rows.Scan(&contactID); err != nil {
+			return nil, fmt.Errorf("failed to scan contact ID: %w", err)
+		}
+		if contactID != userID {
+			contactIDs = append(contactIDs, contactID)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating contacts: %w", err)
+	}
+
+	return contactIDs, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scan reads presence columns selected as: id, last_seen_at, away, status_text
+func (pr *PresenceRepository) scan(row rowScanner, presence *models.Presence) error {
+	var lastSeenAt sql.NullTime
+	if err := row.Scan(&presence.UserID, &lastSeenAt, &presence.Away, &presence.StatusText); err != nil {
+		return err
+	}
+
+	if lastSeenAt.Valid {
+		presence.LastSeenAt = &lastSeenAt.Time
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/squ1ky/talkify/internal/models"
+)
+
+// ReactionRepository handles database operations for message reactions
+// A user may react to a message with several emoji, each at most once
+type ReactionRepository struct {
+	db *DB
+}
+
+// NewReactionRepository creates a new reaction repository
+func NewReactionRepository(db *DB) *ReactionRepository {
+	return &ReactionRepository{db: db}
+}
+
+// Add stores user's reaction and marks the message updated, returns false if it already existed
+func (rc *ReactionRepository) Add(messageID, userID int, emoji string) (bool, error) {
+	query := `
+		WITH added AS (
+			INSERT INTO message_reactions (message_id, user_id, emoji, created_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (message_id, user_id, emoji) DO NOTHING
+			RETURNING message_id
+		)
+		UPDATE messages SET updated_at = NOW() WHERE id IN (SELECT message_id FROM added)`
+
+	result, err := rc.db.Exec(query, messageID, userID, emoji)
+	if err != nil {
+		return false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Remove deletes user's reaction and marks the message updated, returns false if there was none
+func (rc *ReactionRepository) Remove(messageID, userID int, emoji string) (bool, error) {
+	query := `
+		WITH removed AS (
+			DELETE FROM message_reactions
+			WHERE message_id = $1 AND user_id = $2 AND emoji = $3
+			RETURNING message_id
+		)
+		UPDATE messages SET updated_at = NOW() WHERE id IN (SELECT message_id FROM removed)`
+
+	result, err := rc.db.Exec(query, messageID, userID, emoji)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Count returns number of users who reacted to a message with the emoji
+func (rc *ReactionRepository) Count(messageID int, emoji string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM message_reactions WHERE message_id = $1 AND emoji = $2`
+
+	if err := rc.db.QueryRow(query, messageID, emoji).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count reactions: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetSummaries returns reactions of messages aggregated by emoji as seen by viewerID,
+// emoji are ordered by their first use on the message
+func (rc *ReactionRepository) GetSummaries(messageIDs []int, viewerID int) (map[int][]models.ReactionSummary, error) {
+	query := `
+		SELECT message_id, emoji, COUNT(*), BOOL_OR(user_id = $2)
+		FROM message_reactions
+		WHERE message_id = ANY($1)
+		GROUP BY message_id, emoji
+		ORDER BY message_id, MIN(created_at), emoji`
+
+	rows, err := rc.db.Query(query, pq.Array(messageIDs), viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reactions: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := make(map[int][]models.ReactionSummary)
+	for rows.Next() {
+		var messageID int
+		var summary models.ReactionSummary
+		if err := rows.Scan(&messageID, &summary.Emoji, &summary.Count, &summary.Reacted); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction row: %w", err)
+		}
+		summaries[messageID] = append(summaries[messageID], summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reaction rows: %w", err)
+	}
+
+	return summaries, nil
+}
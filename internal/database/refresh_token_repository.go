@@ -0,0 +1,145 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/squ1ky/talkify/internal/models"
+	"time"
+)
+
+// RefreshTokenRepository handles database operations for refresh tokens
+type RefreshTokenRepository struct {
+	db *DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create stores a new refresh token
+func (rt *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	err := rt.db.QueryRow(
+		query,
+		token.UserID,
+		token.TokenHash,
+		token.FamilyID,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a refresh token by hash of its value
+func (rt *RefreshTokenRepository) GetByHash(tokenHash string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	query := `
+		SELECT id, user_id, token_hash, family_id, expires_at, created_at, rotated_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1`
+
+	err := rt.db.QueryRow(query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.FamilyID,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.RotatedAt,
+		&token.RevokedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("refresh token not found")
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Rotate marks token as used and stores its successor in one transaction
+// Returns false if token was already rotated or revoked, e.g. by a concurrent request
+func (rt *RefreshTokenRepository) Rotate(tokenID int, next *models.RefreshToken) (bool, error) {
+	tx, err := rt.db.BeginTx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rotateQuery := `
+		UPDATE refresh_tokens SET rotated_at = $1
+		WHERE id = $2 AND rotated_at IS NULL AND revoked_at IS NULL`
+
+	result, err := tx.Exec(rotateQuery, next.CreatedAt, tokenID)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	insertQuery := `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	err = tx.QueryRow(
+		insertQuery,
+		next.UserID,
+		next.TokenHash,
+		next.FamilyID,
+		next.ExpiresAt,
+		next.CreatedAt,
+	).Scan(&next.ID)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit refresh token rotation: %w", err)
+	}
+
+	return true, nil
+}
+
+// RevokeFamily revokes every token issued by rotation from the same login
+func (rt *RefreshTokenRepository) RevokeFamily(familyID string, revokedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+
+	if _, err := rt.db.Exec(query, revokedAt, familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUser revokes all refresh tokens of a user
+func (rt *RefreshTokenRepository) RevokeUser(userID int, revokedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+
+	if _, err := rt.db.Exec(query, revokedAt, userID); err != nil {
+		return fmt.Errorf("failed to revoke user refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RevocationRepository handles database operations for revoked access tokens
+// Single tokens are revoked by jti, all tokens of a user by bumping users.token_version
+type RevocationRepository struct {
+	db *DB
+}
+
+// NewRevocationRepository creates a new revocation repository
+func NewRevocationRepository(db *DB) *RevocationRepository {
+	return &RevocationRepository{db: db}
+}
+
+// GetTokenVersion returns current token version of a user
+func (rr *RevocationRepository) GetTokenVersion(userID int) (int, error) {
+	var version int
+	query := `SELECT token_version FROM users WHERE id = $1`
+
+	if err := rr.db.QueryRow(query, userID).Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("user not found")
+		}
+		return 0, fmt.Errorf("failed to get token version: %w", err)
+	}
+
+	return version, nil
+}
+
+// IncrementTokenVersion invalidates all access tokens issued to a user so far
+func (rr *RevocationRepository) IncrementTokenVersion(userID int) error {
+	query := `UPDATE users SET token_version = token_version + 1 WHERE id = $1`
+
+	result, err := rr.db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment token version: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// RevokeToken stores jti of a revoked token until the token expires
+// Entries of already expired tokens are pruned on the way
+func (rr *RevocationRepository) RevokeToken(jti string, userID int, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING`
+
+	now := time.Now()
+	if _, err := rr.db.Exec(query, jti, userID, expiresAt, now); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	if _, err := rr.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < $1`, now); err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+
+	return nil
+}
+
+// IsRevoked checks if token was revoked by jti or issued before user's current token version
+// Tokens of deleted users are reported as revoked
+func (rr *RevocationRepository) IsRevoked(userID int, jti string, version int) (bool, error) {
+	query := `
+		SELECT u.token_version <> $3 OR EXISTS (
+			SELECT 1 FROM revoked_tokens r WHERE r.jti = $2
+		)
+		FROM users u
+		WHERE u.id = $1`
+
+	var revoked bool
+	if err := rr.db.QueryRow(query, userID, jti, version).Scan(&revoked); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	return revoked, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/squ1ky/talkify/internal/models"
+)
+
+// TicketRepository handles database operations for WebSocket tickets
+type TicketRepository struct {
+	db *DB
+}
+
+// NewTicketRepository creates a new ticket repository
+func NewTicketRepository(db *DB) *TicketRepository {
+	return &TicketRepository{db: db}
+}
+
+// Create stores a new ticket, pruning expired ones on the way
+func (tr *TicketRepository) Create(ticket *models.WSTicket) error {
+	query := `
+		INSERT INTO ws_tickets (ticket_hash, user_id, username, token_id, token_version, token_expires_at, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := tr.db.Exec(
+		query,
+		ticket.TicketHash,
+		ticket.UserID,
+		ticket.Username,
+		ticket.TokenID,
+		ticket.TokenVersion,
+		ticket.TokenExpiresAt,
+		ticket.ExpiresAt,
+		ticket.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %w", err)
+	}
+
+	if _, err := tr.db.Exec(`DELETE FROM ws_tickets WHERE expires_at < $1`, ticket.CreatedAt); err != nil {
+		return fmt.Errorf("failed to prune expired tickets: %w", err)
+	}
+
+	return nil
+}
+
+// Consume deletes ticket and returns it, so every ticket can be used only once
+func (tr *TicketRepository) Consume(ticketHash string) (*models.WSTicket, error) {
+	ticket := &models.WSTicket{}
+	query := `
+		DELETE FROM ws_tickets
+		WHERE ticket_hash = $1
+		RETURNING ticket_hash, user_id, username, token_id, token_version, token_expires_at, expires_at, created_at`
+
+	err := tr.db.QueryRow(query, ticketHash).Scan(
+		&ticket.TicketHash,
+		&ticket.UserID,
+		&ticket.Username,
+		&ticket.TokenID,
+		&ticket.TokenVersion,
+		&ticket.TokenExpiresAt,
+		&ticket.ExpiresAt,
+		&ticket.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("ticket not found")
+		}
+		return nil, fmt.Errorf("failed to consume ticket: %w", err)
+	}
+
+	return ticket, nil
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/squ1ky/talkify/internal/models"
+	"github.com/squ1ky/talkify/internal/services"
+	"net/http"
+	"strconv"
+)
+
+// ConversationHandler handles group conversation API requests
+type ConversationHandler struct {
+	conversations *services.ConversationService
+}
+
+// NewConversationHandler creates a new conversation handler
+func NewConversationHandler(conversations *services.ConversationService) *ConversationHandler {
+	return &ConversationHandler{conversations: conversations}
+}
+
+// RegisterProtectedRoutes applies routes on group (/api/v1, secured by JWT-middleware)
+func (h *ConversationHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.POST("/conversations", h.CreateConversation)
+	rg.GET("/conversations/groups", h.GetGroups)
+	rg.GET("/conversations/:id", h.GetConversation)
+	rg.PATCH("/conversations/:id", h.RenameConversation)
+	rg.GET("/conversations/:id/messages", h.GetHistory)
+	rg.POST("/conversations/:id/members", h.AddMember)
+	rg.PATCH("/conversations/:id/members/:userID", h.UpdateMemberRole)
+	rg.DELETE("/conversations/:id/members/:userID", h.RemoveMember)
+	rg.POST("/conversations/:id/leave", h.Leave)
+}
+
+// CreateConversation POST /conversations
+func (h *ConversationHandler) CreateConversation(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	var req models.ConversationCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.conversations.Create(currentID, req)
+	if err != nil {
+		writeConversationError(c, err, "failed to create conversation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetGroups GET /conversations/groups
+func (h *ConversationHandler) GetGroups(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	conversations, err := h.conversations.ListForUser(currentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get conversations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConversationListResponse{
+		Conversations: conversations,
+		Total:         len(conversations),
+	})
+}
+
+// GetConversation GET /conversations/:id
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	conversationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	resp, err := h.conversations.Get(currentID, conversationID)
+	if err != nil {
+		writeConversationError(c, err, "failed to get conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RenameConversation PATCH /conversations/:id
+func (h *ConversationHandler) RenameConversation(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	conversationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ConversationUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.conversations.Rename(currentID, conversationID, req)
+	if err != nil {
+		writeConversationError(c, err, "failed to rename conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetHistory GET /conversations/:id/messages
+func (h *ConversationHandler) GetHistory(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	conversationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	conversation, err := h.conversations.Get(currentID, conversationID)
+	if err != nil {
+		writeConversationError(c, err, "failed to get conversation")
+		return
+	}
+
+	limit, offset := parseLimitOffset(c, 50, 0)
+
+	messages, total, err := h.conversations.GetHistory(currentID, conversationID, limit, offset)
+	if err != nil {
+		writeConversationError(c, err, "failed to get conversation history")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConversationHistoryResponse{
+		Messages:     messages,
+		Total:        total,
+		Conversation: *conversation,
+	})
+}
+
+// AddMember POST /conversations/:id/members
+func (h *ConversationHandler) AddMember(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	conversationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ConversationMemberAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.conversations.AddMember(currentID, conversationID, req)
+	if err != nil {
+		writeConversationError(c, err, "failed to add member")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateMemberRole PATCH /conversations/:id/members/:userID
+func (h *ConversationHandler) UpdateMemberRole(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	conversationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	targetID, ok := parseIDParam(c, "userID")
+	if !ok {
+		return
+	}
+
+	var req models.ConversationMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.conversations.UpdateMemberRole(currentID, conversationID, targetID, req)
+	if err != nil {
+		writeConversationError(c, err, "failed to update member role")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RemoveMember DELETE /conversations/:id/members/:userID
+func (h *ConversationHandler) RemoveMember(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	conversationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	targetID, ok := parseIDParam(c, "userID")
+	if !ok {
+		return
+	}
+
+	if err := h.conversations.RemoveMember(currentID, conversationID, targetID); err != nil {
+		writeConversationError(c, err, "failed to remove member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Leave POST /conversations/:id/leave
+func (h *ConversationHandler) Leave(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	conversationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.conversations.Leave(currentID, conversationID); err != nil {
+		writeConversationError(c, err, "failed to leave conversation")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// writeConversationError maps conversation service errors to HTTP responses
+func writeConversationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrConversationNotFound), errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrNotMember), errors.Is(err, services.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrAlreadyMember):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fallback,
+		})
+	}
+}
+
+// parseIDParam parses positive integer path parameter, responds with 400 on failure
+func parseIDParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid " + name,
+		})
+		return 0, false
+	}
+	return id, true
+}
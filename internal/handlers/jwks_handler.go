@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/squ1ky/talkify/internal/services"
+	"net/http"
+)
+
+// JWKSHandler serves public signing keys so other services can verify talkify tokens
+type JWKSHandler struct {
+	keys *services.KeySet
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(keys *services.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// RegisterRoutes adds well-known routes to the root of the router
+func (h *JWKSHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/.well-known/jwks.json", h.GetJWKS)
+}
+
+// GetJWKS GET /.well-known/jwks.json
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
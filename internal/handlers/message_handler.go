@@ -5,25 +5,34 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/squ1ky/talkify/internal/models"
 	"github.com/squ1ky/talkify/internal/services"
+	ws "github.com/squ1ky/talkify/internal/websocket"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // MessageHandler handles message-related API requests
 type MessageHandler struct {
 	messages *services.MessageService
 	users    *services.UserService
+	hub      *ws.Hub
 }
 
 // NewMessageHandler creates a new message handler
-func NewMessageHandler(messages *services.MessageService, users *services.UserService) *MessageHandler {
-	return &MessageHandler{messages: messages, users: users}
+func NewMessageHandler(messages *services.MessageService, users *services.UserService, hub *ws.Hub) *MessageHandler {
+	return &MessageHandler{messages: messages, users: users, hub: hub}
 }
 
 // RegisterProtectedRoutes applies routes on group (/api/v1, secured by JWT-middleware)
 func (h *MessageHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
 	rg.POST("/messages", h.SendMessage)
+	rg.GET("/messages/sync", h.SyncMessages)
 	rg.GET("/messages/:userID", h.GetConversation)
+	rg.PATCH("/messages/:id", h.EditMessage)
+	rg.DELETE("/messages/:id", h.DeleteMessage)
+	rg.POST("/messages/:id/read", h.MarkRead)
+	rg.POST("/messages/:id/reactions", h.AddReaction)
+	rg.DELETE("/messages/:id/reactions", h.RemoveReaction)
 	rg.GET("/conversations", h.GetConversations)
 }
 
@@ -50,10 +59,15 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 	resp, err := h.messages.SendMessage(senderID, req)
 	if err != nil {
 		switch {
-		case errors.Is(err, services.ErrInvalidContent):
+		case errors.Is(err, services.ErrInvalidContent), errors.Is(err, services.ErrInvalidRecipient),
+			errors.Is(err, services.ErrInvalidReply):
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": err.Error(),
 			})
+		case errors.Is(err, services.ErrNotMember):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": err.Error(),
+			})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "failed to send message",
@@ -62,9 +76,252 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
+	// Deliver to connected devices of sender and recipients like a WebSocket-sent message
+	h.hub.PublishEvent(ws.EventMessage, resp)
+
 	c.JSON(http.StatusCreated, resp)
 }
 
+// EditMessage PATCH /messages/:id
+func (h *MessageHandler) EditMessage(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	messageID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.MessageUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.messages.EditMessage(currentID, messageID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidContent):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrMsgNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrNotSender):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to edit message",
+			})
+		}
+		return
+	}
+
+	h.hub.PublishEvent(ws.EventMessageEdited, resp)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteMessage DELETE /messages/:id?scope=me|everyone
+func (h *MessageHandler) DeleteMessage(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	messageID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	scope := c.DefaultQuery("scope", models.DeleteScopeMe)
+
+	resp, err := h.messages.DeleteMessage(currentID, messageID, scope)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidScope):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrMsgNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrNotSender):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to delete message",
+			})
+		}
+		return
+	}
+
+	if scope == models.DeleteScopeEveryone {
+		h.hub.PublishEvent(ws.EventMessageDeleted, resp)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkRead POST /messages/:id/read
+// Marks every message of the conversation up to :id as read
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	messageID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	receipts, err := h.messages.MarkRead(currentID, messageID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMsgNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to mark messages read",
+			})
+		}
+		return
+	}
+
+	h.hub.PublishReceipts(receipts)
+
+	marked := 0
+	for _, r := range receipts {
+		marked += len(r.MessageIDs)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"marked": marked,
+	})
+}
+
+// AddReaction POST /messages/:id/reactions
+// Responds 201 with the change, or 204 if user already reacted with this emoji
+func (h *MessageHandler) AddReaction(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	messageID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	reaction, err := h.messages.React(currentID, messageID, req.Emoji)
+	if err != nil {
+		writeReactionError(c, err)
+		return
+	}
+
+	if reaction == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	h.hub.PublishReaction(reaction)
+
+	c.JSON(http.StatusCreated, reaction)
+}
+
+// RemoveReaction DELETE /messages/:id/reactions?emoji=
+func (h *MessageHandler) RemoveReaction(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	messageID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	emoji := c.Query("emoji")
+	if emoji == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "emoji is required",
+		})
+		return
+	}
+
+	reaction, err := h.messages.Unreact(currentID, messageID, emoji)
+	if err != nil {
+		writeReactionError(c, err)
+		return
+	}
+
+	if reaction != nil {
+		h.hub.PublishReaction(reaction)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// writeReactionError maps reaction errors to HTTP responses
+func writeReactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidEmoji):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrMsgNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update reaction",
+		})
+	}
+}
+
+// SyncMessages GET /messages/sync?since=&since_id=&limit=
+// Returns messages missed since given RFC3339 time or message ID, oldest first
+func (h *MessageHandler) SyncMessages(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	since, sinceID, err := parseSyncParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	limit, _ := parseLimitOffset(c, 100, 0)
+	if limit > 500 {
+		limit = 500
+	}
+
+	resp, err := h.messages.GetMessagesSince(currentID, since, sinceID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to sync messages",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // GetConversation GET /messages/:userID
 func (h *MessageHandler) GetConversation(c *gin.Context) {
 	uid, _ := c.Get("user_id")
@@ -116,7 +373,7 @@ func (h *MessageHandler) GetConversations(c *gin.Context) {
 		limit = 50
 	}
 
-	users, err := h.messages.GetRecentConversations(currentID, limit)
+	conversations, err := h.messages.GetRecentConversations(currentID, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to get conversations",
@@ -125,11 +382,40 @@ func (h *MessageHandler) GetConversations(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total": len(users),
-		"users": users,
+		"total":         len(conversations),
+		"conversations": conversations,
 	})
 }
 
+// parseSyncParams parses ?since= (RFC3339) and ?since_id=, at least one is required
+func parseSyncParams(c *gin.Context) (time.Time, int, error) {
+	sinceStr := c.Query("since")
+	sinceIDStr := c.Query("since_id")
+	if sinceStr == "" && sinceIDStr == "" {
+		return time.Time{}, 0, errors.New("since or since_id is required")
+	}
+
+	var since time.Time
+	if sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return time.Time{}, 0, errors.New("since must be RFC3339 timestamp")
+		}
+		since = parsed
+	}
+
+	sinceID := 0
+	if sinceIDStr != "" {
+		parsed, err := strconv.Atoi(sinceIDStr)
+		if err != nil || parsed < 0 {
+			return time.Time{}, 0, errors.New("since_id must be non-negative integer")
+		}
+		sinceID = parsed
+	}
+
+	return since, sinceID, nil
+}
+
 // parseLimitOffset parses ?limit=&offset=
 func parseLimitOffset(c *gin.Context, defLimit, defOffset int) (int, int) {
 	limitStr := c.DefaultQuery("limit", strconv.Itoa(defLimit))
@@ -2,21 +2,33 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/squ1ky/talkify/internal/middleware"
 	"github.com/squ1ky/talkify/internal/models"
 	"github.com/squ1ky/talkify/internal/services"
+	ws "github.com/squ1ky/talkify/internal/websocket"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+// maxPresenceIDs caps number of users in a single presence lookup
+const maxPresenceIDs = 200
+
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
-	userService *services.UserService
-	jwtService  *services.JWTService
+	userService     *services.UserService
+	authService     *services.AuthService
+	presenceService *services.PresenceService
+	hub             *ws.Hub
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userService *services.UserService, jwtService *services.JWTService) *UserHandler {
-	return &UserHandler{userService: userService, jwtService: jwtService}
+func NewUserHandler(userService *services.UserService, authService *services.AuthService, presenceService *services.PresenceService, hub *ws.Hub) *UserHandler {
+	return &UserHandler{userService: userService, authService: authService, presenceService: presenceService, hub: hub}
 }
 
 // RegisterPublicRoutes adds public user routes (no auth required)
@@ -24,11 +36,17 @@ func (h *UserHandler) RegisterPublicRoutes(rg *gin.RouterGroup) {
 	auth := rg.Group("/auth")
 	auth.POST("/register", h.Register)
 	auth.POST("/login", h.Login)
+	auth.POST("/refresh", h.Refresh)
 }
 
 // RegisterProtectedRoutes adds protected user routes (auth required)
 func (h *UserHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
 	rg.GET("/users", h.GetUsers)
+	rg.GET("/users/:id", h.GetUser)
+	rg.GET("/presence", h.GetPresence)
+	rg.PUT("/presence", h.UpdatePresence)
+	rg.POST("/auth/logout", h.Logout)
+	rg.POST("/auth/logout-all", h.LogoutAll)
 }
 
 // Register handles user registration
@@ -79,7 +97,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.jwtService.GenerateToken(user.ID, user.Username)
+	tokens, err := h.authService.IssueTokens(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to generate token",
@@ -87,12 +105,89 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user":  user.ToResponse(),
-		"token": token,
+	c.JSON(http.StatusOK, models.LoginResponse{
+		User:          user.ToResponse(),
+		TokenResponse: *tokens,
 	})
 }
 
+// Refresh exchanges refresh token for a new access and refresh token pair
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRefreshToken), errors.Is(err, services.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to refresh token",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes current access token and closes WebSocket connections opened with it
+func (h *UserHandler) Logout(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var req models.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.Logout(claims.UserID, claims.ID, claims.ExpiresAt.Time, req.RefreshToken); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to log out",
+			})
+		}
+		return
+	}
+
+	h.hub.DisconnectUser(claims.UserID, claims.ID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll revokes all tokens of current user and closes all their WebSocket connections
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	if err := h.authService.LogoutAll(currentID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to log out",
+		})
+		return
+	}
+
+	h.hub.DisconnectUser(currentID, "")
+
+	c.Status(http.StatusNoContent)
+}
+
 // GetUsers handles listing all users
 func (h *UserHandler) GetUsers(c *gin.Context) {
 	limit, offset := parseLimitOffset(c, 50, 0)
@@ -105,8 +200,138 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 		return
 	}
 
+	h.attachPresence(users)
+
 	c.JSON(http.StatusOK, models.UserListResponse{
 		Users: users,
 		Total: total,
 	})
 }
+
+// GetUser GET /users/:id
+func (h *UserHandler) GetUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	users := []models.UserResponse{*user}
+	h.attachPresence(users)
+
+	c.JSON(http.StatusOK, users[0])
+}
+
+// GetPresence GET /presence?user_ids=1,2,3
+func (h *UserHandler) GetPresence(c *gin.Context) {
+	userIDs, err := parseIDList(c.Query("user_ids"), maxPresenceIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	presences, err := h.hub.GetPresence(userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get presence",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PresenceListResponse{
+		Presence: presences,
+	})
+}
+
+// UpdatePresence PUT /presence
+// Switches current user between online and away and sets custom status text
+func (h *UserHandler) UpdatePresence(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	currentID := uid.(int)
+
+	var req models.PresenceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	presence, err := h.presenceService.Update(currentID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPresence):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to update presence",
+			})
+		}
+		return
+	}
+
+	presence.Resolve(h.hub.IsUserOnline(currentID))
+	h.hub.PublishPresence(presence)
+
+	c.JSON(http.StatusOK, presence)
+}
+
+// attachPresence fills presence of users, leaving it empty if lookup fails
+func (h *UserHandler) attachPresence(users []models.UserResponse) {
+	if len(users) == 0 {
+		return
+	}
+
+	userIDs := make([]int, 0, len(users))
+	for _, u := range users {
+		userIDs = append(userIDs, u.ID)
+	}
+
+	presences, err := h.hub.GetPresence(userIDs)
+	if err != nil {
+		log.Printf("Failed to get presence of users: %v", err)
+		return
+	}
+
+	byID := make(map[int]*models.Presence, len(presences))
+	for i := range presences {
+		byID[presences[i].UserID] = &presences[i]
+	}
+	for i := range users {
+		users[i].Presence = byID[users[i].ID]
+	}
+}
+
+// parseIDList parses comma-separated list of positive IDs, at least one and at most limit
+func parseIDList(s string, limit int) ([]int, error) {
+	if s == "" {
+		return nil, errors.New("user_ids is required")
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) > limit {
+		return nil, fmt.Errorf("at most %d user_ids are allowed", limit)
+	}
+
+	ids := make([]int, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, errors.New("user_ids must be comma-separated positive integers")
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
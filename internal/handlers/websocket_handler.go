@@ -1,67 +1,194 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/squ1ky/talkify/internal/config"
+	"github.com/squ1ky/talkify/internal/metrics"
+	"github.com/squ1ky/talkify/internal/middleware"
+	"github.com/squ1ky/talkify/internal/models"
 	"github.com/squ1ky/talkify/internal/services"
 	ws "github.com/squ1ky/talkify/internal/websocket"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 )
 
+const (
+	// closeUnauthorized is WebSocket close code sent when first-frame authentication fails
+	closeUnauthorized = 4401
+
+	// subprotocol is selected by the server; browsers passing ticket as subprotocol must offer it as well,
+	// because they fail the handshake unless one of the offered subprotocols is echoed back
+	subprotocol = "talkify"
+
+	// resumeKey stores sync cursor of the upgrade request in gin context
+	resumeKey = "ws_resume"
+)
+
+// WebSocketAuthenticator authenticates WebSocket upgrades and issues tickets for them
+type WebSocketAuthenticator interface {
+	services.TokenValidator
+	services.TicketRedeemer
+	IssueTicket(claims *services.JWTClaims) (*models.WSTicketResponse, error)
+}
+
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
 	hub         *ws.Hub
-	userService *services.UserService
+	authService WebSocketAuthenticator
+	origins     *middleware.OriginPolicy
+	config      config.WebSocketConfig
+	upgrader    websocket.Upgrader
 }
 
-// upgrader converts HTTP connection to WebSocket
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+// NewWebSocketHandler creates a new WebSocket handler
+func NewWebSocketHandler(hub *ws.Hub, authService WebSocketAuthenticator, origins *middleware.OriginPolicy, cfg config.WebSocketConfig) *WebSocketHandler {
+	return &WebSocketHandler{
+		hub:         hub,
+		authService: authService,
+		origins:     origins,
+		config:      cfg,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			Subprotocols:    []string{subprotocol},
+			CheckOrigin:     origins.CheckRequest,
+		},
+	}
 }
 
-// NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler(hub *ws.Hub, userService *services.UserService) *WebSocketHandler {
-	return &WebSocketHandler{hub: hub, userService: userService}
+// RegisterRoutes adds WebSocket upgrade route, authenticated by token, ticket or first frame
+func (h *WebSocketHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/ws/chat", h.checkOrigin, h.checkUpgrade, middleware.WebSocketAuth(h.authService, h.authService, h.config.AllowFrameAuth), h.HandleWebSocketConnection)
 }
 
-// HandleWebSocketConnection upgrades HTTP to WebSocket and manages client
-// Route: GET /ws/chat
-func (h *WebSocketHandler) HandleWebSocketConnection(c *gin.Context) {
-	userIDInterface, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "user_id not found",
+// checkOrigin rejects upgrades from origins outside the allow-list before any ticket is spent
+func (h *WebSocketHandler) checkOrigin(c *gin.Context) {
+	if h.origins.CheckRequest(c.Request) {
+		c.Next()
+		return
+	}
+
+	metrics.WSRejectedUpgrades.Add(1)
+	log.Printf("Rejected WebSocket upgrade from origin %q (%s)", c.GetHeader("Origin"), c.ClientIP())
+
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error": "origin not allowed",
+	})
+}
+
+// syncCursor is position clients resume from with ?since= or ?since_id=
+type syncCursor struct {
+	since   time.Time
+	sinceID int
+}
+
+// checkUpgrade rejects malformed upgrade requests before any ticket is spent
+// Sync cursor of a resuming client is validated and stored for HandleWebSocketConnection
+func (h *WebSocketHandler) checkUpgrade(c *gin.Context) {
+	protocols := websocket.Subprotocols(c.Request)
+	if hasTicketSubprotocol(protocols) && !slices.Contains(protocols, subprotocol) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "ticket subprotocol must be offered along with " + subprotocol,
 		})
 		return
 	}
 
-	userID := userIDInterface.(int)
+	if c.Query("since") != "" || c.Query("since_id") != "" {
+		since, sinceID, err := parseSyncParams(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.Set(resumeKey, syncCursor{since: since, sinceID: sinceID})
+	}
+
+	c.Next()
+}
 
-	user, err := h.userService.GetByID(userID)
+// hasTicketSubprotocol reports whether client passes ticket as one of offered subprotocols
+func hasTicketSubprotocol(protocols []string) bool {
+	for _, protocol := range protocols {
+		if strings.HasPrefix(protocol, middleware.TicketSubprotocolPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterProtectedRoutes applies routes on group (/api/v1, secured by JWT-middleware)
+func (h *WebSocketHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.POST("/ws/ticket", h.IssueTicket)
+	rg.GET("/online-users", h.GetOnlineUsers)
+}
+
+// IssueTicket POST /ws/ticket
+// Returns single-use ticket for opening /ws/chat from browsers
+func (h *WebSocketHandler) IssueTicket(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	ticket, err := h.authService.IssueTicket(claims)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "user not found",
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to issue ticket",
 		})
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// HandleWebSocketConnection upgrades HTTP to WebSocket and manages client
+// Route: GET /ws/chat[?ticket=|since=|since_id=]
+// Browsers may pass ticket as subprotocol "ticket.<ticket>" offered along with "talkify"
+func (h *WebSocketHandler) HandleWebSocketConnection(c *gin.Context) {
+	claims, authenticated := middleware.GetClaims(c)
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("Failed to upgrade connection for user %d: %v", userID, err)
+		log.Printf("Failed to upgrade connection: %v", err)
 		return
 	}
 
+	if !authenticated {
+		claims, err = h.authenticateFrame(conn)
+		if err != nil {
+			log.Printf("WebSocket first-frame authentication failed: %v", err)
+			conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(closeUnauthorized, err.Error()),
+				time.Now().Add(h.config.WriteWait),
+			)
+			conn.Close()
+			return
+		}
+	}
+
+	userID := claims.UserID
+
 	client := &ws.Client{
 		Conn:     conn,
 		UserID:   userID,
-		Username: user.Username,
-		Send:     make(chan []byte, 256),
+		Username: claims.Username,
+		Send:     make(chan []byte, h.config.SendBufferSize),
 		Hub:      h.hub,
+		TokenID:  claims.ID,
+	}
+
+	// Optional resume: ?since= or ?since_id= replays missed messages before live traffic
+	if value, ok := c.Get(resumeKey); ok {
+		cursor := value.(syncCursor)
+		client.Resume = &ws.SyncRequest{
+			Client:  client,
+			Since:   cursor.since,
+			SinceID: cursor.sinceID,
+		}
 	}
 
 	h.hub.Register <- client
@@ -69,12 +196,43 @@ func (h *WebSocketHandler) HandleWebSocketConnection(c *gin.Context) {
 	go client.WritePump()
 	go client.ReadPump()
 
-	log.Printf("WebSocket connection established for user %d (%s), userID, user.Username")
+	log.Printf("WebSocket connection established for user %d (%s)", userID, claims.Username)
 }
 
-// RegisterRoutes adds WebSocketRoutes to router group
-func (h *WebSocketHandler) RegisterRoutes(rg *gin.RouterGroup) {
-	rg.GET("/ws/chat", h.HandleWebSocketConnection)
+// authenticateFrame authenticates connection by its first frame: {"type":"auth","token"|"ticket":...}
+// The frame must arrive within AuthTimeout
+func (h *WebSocketHandler) authenticateFrame(conn *websocket.Conn) (*services.JWTClaims, error) {
+	conn.SetReadLimit(h.config.MaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(h.config.AuthTimeout))
+
+	var msg ws.IncomingMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		return nil, errors.New("auth frame expected")
+	}
+	if msg.Type != "auth" {
+		return nil, errors.New("first frame must be auth")
+	}
+
+	var claims *services.JWTClaims
+	var err error
+	switch {
+	case msg.Token != "":
+		claims, err = h.authService.ValidateToken(msg.Token)
+	case msg.Ticket != "":
+		claims, err = h.authService.RedeemTicket(msg.Ticket)
+	default:
+		return nil, errors.New("token or ticket required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(h.config.WriteWait))
+	if err := conn.WriteJSON(ws.OutgoingMessage{Type: ws.EventAuthenticated, Timestamp: time.Now()}); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
 }
 
 // GetOnlineUsers returns list of online users for REST API
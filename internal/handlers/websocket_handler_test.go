@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/squ1ky/talkify/internal/config"
+	"github.com/squ1ky/talkify/internal/database"
+	"github.com/squ1ky/talkify/internal/middleware"
+	"github.com/squ1ky/talkify/internal/models"
+	"github.com/squ1ky/talkify/internal/services"
+	ws "github.com/squ1ky/talkify/internal/websocket"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// fakeTickets redeems tickets from memory and records which were spent
+type fakeTickets struct {
+	mu       sync.Mutex
+	tickets  map[string]*services.JWTClaims
+	redeemed []string
+}
+
+func (f *fakeTickets) ValidateToken(string) (*services.JWTClaims, error) {
+	return nil, services.ErrInvalidToken
+}
+
+func (f *fakeTickets) RedeemTicket(ticket string) (*services.JWTClaims, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.redeemed = append(f.redeemed, ticket)
+	claims, ok := f.tickets[ticket]
+	if !ok {
+		return nil, services.ErrInvalidTicket
+	}
+	delete(f.tickets, ticket)
+	return claims, nil
+}
+
+func (f *fakeTickets) IssueTicket(*services.JWTClaims) (*models.WSTicketResponse, error) {
+	return nil, services.ErrInvalidTicket
+}
+
+func (f *fakeTickets) spent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.redeemed...)
+}
+
+// newWebSocketServer serves /ws/chat with Hub whose services are backed by a closed database
+func newWebSocketServer(t *testing.T, tickets *fakeTickets) *httptest.Server {
+	t.Helper()
+
+	sqlDB, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB.Close()
+	db := &database.DB{DB: sqlDB}
+
+	messageService := services.NewMessageService(
+		database.NewMessageRepository(db),
+		database.NewUserRepository(db),
+		database.NewConversationRepository(db),
+		database.NewReactionRepository(db),
+	)
+	presenceService := services.NewPresenceService(database.NewPresenceRepository(db))
+
+	cfg := config.WebSocketConfig{
+		PingInterval:   time.Minute,
+		PongWait:       2 * time.Minute,
+		WriteWait:      time.Second,
+		MaxMessageSize: 4096,
+		SendBufferSize: 16,
+	}
+	hub := ws.NewHub(messageService, presenceService, nil, cfg)
+	go hub.Run()
+
+	router := gin.New()
+	NewWebSocketHandler(hub, tickets, middleware.NewOriginPolicy(nil), cfg).RegisterRoutes(router.Group("/api/v1"))
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebSocketTicketSubprotocol(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		protocols  []string
+		wantStatus int
+		wantSpent  bool
+	}{
+		{"offered with talkify", "", []string{"talkify", "ticket.abc"}, http.StatusSwitchingProtocols, true},
+		{"offered alone", "", []string{"ticket.abc"}, http.StatusBadRequest, false},
+		{"invalid sync cursor", "?since_id=-1", []string{"talkify", "ticket.abc"}, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tickets := &fakeTickets{tickets: map[string]*services.JWTClaims{
+				"abc": {UserID: 1, Username: "alice"},
+			}}
+			server := newWebSocketServer(t, tickets)
+
+			url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/chat" + tt.query
+			dialer := websocket.Dialer{Subprotocols: tt.protocols}
+
+			conn, resp, err := dialer.Dial(url, nil)
+			if resp == nil {
+				t.Fatalf("Dial() failed without response: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("handshake status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if conn != nil {
+				defer conn.Close()
+				if got := conn.Subprotocol(); got != "talkify" {
+					t.Errorf("selected subprotocol = %q, want talkify", got)
+				}
+			}
+
+			if spent := len(tickets.spent()) > 0; spent != tt.wantSpent {
+				t.Errorf("ticket spent = %v, want %v", spent, tt.wantSpent)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package metrics
+
+import "expvar"
+
+// Counters published at /debug/vars of the admin listener
+var (
+	// WSRejectedUpgrades counts WebSocket upgrades refused because of their Origin
+	WSRejectedUpgrades = expvar.NewInt("ws_rejected_upgrades")
+
+	// WSDroppedMessages counts outgoing WebSocket messages not queued because send queue was full
+	WSDroppedMessages = expvar.NewInt("ws_dropped_messages")
+
+	// WSSlowConsumerDisconnects counts connections closed because they could not keep up
+	WSSlowConsumerDisconnects = expvar.NewInt("ws_slow_consumer_disconnects")
+)
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CORS preflight response headers
+const (
+	corsAllowMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders = "Authorization, Content-Type"
+	corsMaxAge       = "600"
+)
+
+// OriginPolicy decides which browser origins may call the API and open WebSockets
+// Entries are "scheme://host[:port]", "scheme://*.domain[:port]" for any subdomain, or "*" for any origin
+type OriginPolicy struct {
+	allowAll  bool
+	exact     map[string]bool
+	wildcards []originPattern
+}
+
+// originPattern is a wildcard entry matching subdomains of suffix
+type originPattern struct {
+	scheme string
+	suffix string
+	port   string
+}
+
+// NewOriginPolicy creates origin policy from allow-list, malformed entries are ignored
+func NewOriginPolicy(origins []string) *OriginPolicy {
+	p := &OriginPolicy{exact: make(map[string]bool)}
+
+	for _, origin := range origins {
+		if origin == "*" {
+			p.allowAll = true
+			continue
+		}
+
+		u, err := url.Parse(strings.ToLower(origin))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			continue
+		}
+
+		if strings.HasPrefix(u.Host, "*.") {
+			p.wildcards = append(p.wildcards, originPattern{
+				scheme: u.Scheme,
+				suffix: strings.TrimPrefix(u.Hostname(), "*"),
+				port:   u.Port(),
+			})
+			continue
+		}
+
+		p.exact[u.Scheme+"://"+u.Host] = true
+	}
+
+	return p
+}
+
+// Allowed reports whether origin is on the allow-list
+func (p *OriginPolicy) Allowed(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	if p.exact[u.Scheme+"://"+u.Host] {
+		return true
+	}
+
+	host := u.Hostname()
+	for _, w := range p.wildcards {
+		if u.Scheme == w.scheme && u.Port() == w.port && len(host) > len(w.suffix) && strings.HasSuffix(host, w.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckRequest reports whether request may proceed: without Origin header (non-browser clients),
+// from the server's own origin or from an allowed origin
+func (p *OriginPolicy) CheckRequest(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	return p.Allowed(origin)
+}
+
+// CORS answers preflight requests and allows cross-origin calls from origins accepted by policy
+func CORS(policy *OriginPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !policy.CheckRequest(c.Request) {
+			if preflight {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": "origin not allowed",
+				})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", corsAllowMethods)
+			c.Header("Access-Control-Allow-Headers", corsAllowHeaders)
+			c.Header("Access-Control-Max-Age", corsMaxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,15 +1,18 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/squ1ky/talkify/internal/services"
 	"net/http"
 	"strings"
 )
 
-// JWTMiddleware validates JWT token from Authorization header
-func JWTMiddleware(secret string) gin.HandlerFunc {
+// claimsKey is the gin context key holding *services.JWTClaims of the authenticated request
+const claimsKey = "claims"
+
+// JWTMiddleware authenticates requests by bearer token from Authorization header
+func JWTMiddleware(validator services.TokenValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -26,38 +29,54 @@ func JWTMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-		tokenString := authHeader[7:]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-
-			return []byte(secret), nil
-		})
-
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid token",
-			})
+		if !Authenticate(c, validator, authHeader[7:]) {
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
+		c.Next()
+	}
+}
+
+// Authenticate validates token and stores its claims in the context
+// On failure it aborts the request with an error response and returns false
+func Authenticate(c *gin.Context, validator services.TokenValidator, tokenString string) bool {
+	claims, err := validator.ValidateToken(tokenString)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTokenRevoked):
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid token claims",
+				"error": "token has been revoked",
 			})
-		}
-
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
+		case errors.Is(err, services.ErrInvalidToken):
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "user_id claim not found",
+				"error": "invalid token",
+			})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to validate token",
 			})
-			return
 		}
+		return false
+	}
 
-		c.Set("user_id", int(userIDFloat))
-		c.Next()
+	setClaims(c, claims)
+	return true
+}
+
+// setClaims stores claims of the authenticated request in the context
+func setClaims(c *gin.Context, claims *services.JWTClaims) {
+	c.Set(claimsKey, claims)
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+}
+
+// GetClaims returns claims of the authenticated request
+func GetClaims(c *gin.Context) (*services.JWTClaims, bool) {
+	value, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
 	}
+
+	claims, ok := value.(*services.JWTClaims)
+	return claims, ok
 }
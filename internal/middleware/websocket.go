@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/squ1ky/talkify/internal/services"
+	"net/http"
+	"strings"
+)
+
+// TicketSubprotocolPrefix marks ticket passed in Sec-WebSocket-Protocol, e.g. "ticket.<value>"
+const TicketSubprotocolPrefix = "ticket."
+
+// WebSocketAuth authenticates WebSocket upgrade requests
+// Credentials are taken from Authorization header, ?ticket= query or ticket subprotocol.
+// With allowFrameAuth, requests without credentials pass through to authenticate with the first frame
+func WebSocketAuth(validator services.TokenValidator, tickets services.TicketRedeemer, allowFrameAuth bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+			if !Authenticate(c, validator, authHeader[7:]) {
+				return
+			}
+			c.Next()
+			return
+		}
+
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			ticket = ticketFromSubprotocols(c.GetHeader("Sec-WebSocket-Protocol"))
+		}
+
+		if ticket != "" {
+			if !AuthenticateTicket(c, tickets, ticket) {
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if !allowFrameAuth {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "token or ticket required",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuthenticateTicket redeems ticket and stores claims in the context
+// On failure it aborts the request with an error response and returns false
+func AuthenticateTicket(c *gin.Context, tickets services.TicketRedeemer, ticket string) bool {
+	claims, err := tickets.RedeemTicket(ticket)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTicket), errors.Is(err, services.ErrTokenRevoked):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to validate ticket",
+			})
+		}
+		return false
+	}
+
+	setClaims(c, claims)
+	return true
+}
+
+// ticketFromSubprotocols finds ticket among comma-separated subprotocols offered by client
+func ticketFromSubprotocols(header string) string {
+	for _, protocol := range strings.Split(header, ",") {
+		if ticket, ok := strings.CutPrefix(strings.TrimSpace(protocol), TicketSubprotocolPrefix); ok {
+			return ticket
+		}
+	}
+	return ""
+}
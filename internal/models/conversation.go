@@ -0,0 +1,163 @@
+package models
+
+import "time"
+
+// Conversation member roles
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Conversation types in the conversation list
+const (
+	ConversationTypeDirect = "direct"
+	ConversationTypeGroup  = "group"
+)
+
+// Conversation represents a group chat in the system
+type Conversation struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedBy int       `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ConversationMember represents user's membership in a group chat
+type ConversationMember struct {
+	ConversationID int       `json:"conversation_id" db:"conversation_id"`
+	UserID         int       `json:"user_id" db:"user_id"`
+	Role           string    `json:"role" db:"role"`
+	JoinedAt       time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// ConversationCreateRequest represents request for creating a group chat
+type ConversationCreateRequest struct {
+	Name      string `json:"name" binding:"required,min=1,max=100"`
+	MemberIDs []int  `json:"member_ids" binding:"omitempty,dive,min=1"`
+}
+
+// ConversationUpdateRequest represents request for renaming a group chat
+type ConversationUpdateRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+// ConversationMemberAddRequest represents request for adding a member to a group chat
+type ConversationMemberAddRequest struct {
+	UserID int    `json:"user_id" binding:"required,min=1"`
+	Role   string `json:"role" binding:"omitempty,oneof=admin member"`
+}
+
+// ConversationMemberRoleRequest represents request for changing member's role
+type ConversationMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+// ConversationMemberResponse represents group member data in API responses
+type ConversationMemberResponse struct {
+	User     UserResponse `json:"user"`
+	Role     string       `json:"role"`
+	JoinedAt time.Time    `json:"joined_at"`
+}
+
+// ConversationResponse represents group chat data in API responses
+type ConversationResponse struct {
+	ID        int                          `json:"id"`
+	Name      string                       `json:"name"`
+	CreatedBy int                          `json:"created_by"`
+	CreatedAt time.Time                    `json:"created_at"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+	Members   []ConversationMemberResponse `json:"members,omitempty"`
+}
+
+// ConversationListResponse represents list of group chats in API responses
+type ConversationListResponse struct {
+	Conversations []ConversationResponse `json:"conversations"`
+	Total         int                    `json:"total"`
+}
+
+// MessagePreview represents last message of a conversation in the conversation list
+type MessagePreview struct {
+	ID             int       `json:"id"`
+	Content        string    `json:"content"`
+	SenderID       int       `json:"sender_id"`
+	SenderUsername string    `json:"sender_username"`
+	CreatedAt      time.Time `json:"created_at"`
+	Deleted        bool      `json:"deleted,omitempty"`
+}
+
+// ConversationSummaryResponse represents an entry of user's conversation list
+// User is set for direct chats, ConversationID and Name for groups
+type ConversationSummaryResponse struct {
+	Type           string          `json:"type"`
+	User           *UserResponse   `json:"user,omitempty"`
+	ConversationID int             `json:"conversation_id,omitempty"`
+	Name           string          `json:"name,omitempty"`
+	LastMessage    *MessagePreview `json:"last_message,omitempty"`
+	UnreadCount    int             `json:"unread_count"`
+}
+
+// ConversationHistoryResponse represents chat history of a group
+type ConversationHistoryResponse struct {
+	Messages     []MessageWithUserResponse `json:"messages"`
+	Total        int                       `json:"total"`
+	Conversation ConversationResponse      `json:"conversation"`
+}
+
+// ToResponse converts Conversation to ConversationResponse
+func (c *Conversation) ToResponse() ConversationResponse {
+	return ConversationResponse{
+		ID:        c.ID,
+		Name:      c.Name,
+		CreatedBy: c.CreatedBy,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+// CreateConversationFromRequest creates Conversation from ConversationCreateRequest
+func CreateConversationFromRequest(req ConversationCreateRequest, creatorID int) *Conversation {
+	now := time.Now()
+	return &Conversation{
+		Name:      req.Name,
+		CreatedBy: creatorID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// CanManageMembers reports whether the role allows adding and removing members
+func CanManageMembers(role string) bool {
+	return role == RoleOwner || role == RoleAdmin
+}
+
+// CanRemoveMember reports whether actor's role allows removing a member with target's role
+func CanRemoveMember(actorRole, targetRole string) bool {
+	switch actorRole {
+	case RoleOwner:
+		return targetRole != RoleOwner
+	case RoleAdmin:
+		return targetRole == RoleMember
+	default:
+		return false
+	}
+}
+
+// NextOwner picks the member who takes over when the owner leaves: the oldest admin, otherwise the oldest member
+// members must be ordered by join time; returns 0 when the owner is the last member
+func NextOwner(members []ConversationMember, ownerID int) int {
+	successorID := 0
+	for _, m := range members {
+		if m.UserID == ownerID {
+			continue
+		}
+		if m.Role == RoleAdmin {
+			return m.UserID
+		}
+		if successorID == 0 {
+			successorID = m.UserID
+		}
+	}
+	return successorID
+}
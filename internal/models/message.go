@@ -1,38 +1,91 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Message represents a message in the system
 type Message struct {
-	ID         int       `json:"id" db:"id"`
-	SenderID   int       `json:"sender_id" db:"sender_id"`
-	ReceiverID int       `json:"receiver_id" db:"receiver_id"`
-	Content    string    `json:"content" db:"content"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID             int        `json:"id" db:"id"`
+	SenderID       int        `json:"sender_id" db:"sender_id"`
+	ReceiverID     int        `json:"receiver_id,omitempty" db:"receiver_id"`
+	ConversationID int        `json:"conversation_id,omitempty" db:"conversation_id"`
+	ReplyToID      int        `json:"reply_to_id,omitempty" db:"reply_to_id"`
+	Content        string     `json:"content" db:"content"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	EditedAt       *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+	ReadAt         *time.Time `json:"read_at,omitempty" db:"read_at"`
+
+	// ReplyTo is preview of the quoted message, loaded separately
+	ReplyTo *MessagePreview `json:"reply_to,omitempty" db:"-"`
 }
 
+// Message deletion scopes
+const (
+	DeleteScopeMe       = "me"
+	DeleteScopeEveryone = "everyone"
+)
+
+// Message delivery statuses
+// For group messages delivered/read means by at least one member
+const (
+	StatusSent      = "sent"
+	StatusDelivered = "delivered"
+	StatusRead      = "read"
+)
+
 // MessageCreateRequest represents request for sending a message
+// Exactly one of ReceiverID (direct message) or ConversationID (group message) must be set
+// ReplyToID optionally quotes an earlier message of the same chat
 type MessageCreateRequest struct {
-	ReceiverID int    `json:"receiver_id" binding:"required,min=1"`
-	Content    string `json:"content" binding:"required,min=1,max=1000"`
+	ReceiverID     int    `json:"receiver_id" binding:"omitempty,min=1"`
+	ConversationID int    `json:"conversation_id" binding:"omitempty,min=1"`
+	ReplyToID      int    `json:"reply_to_id" binding:"omitempty,min=1"`
+	Content        string `json:"content" binding:"required,min=1,max=1000"`
+}
+
+// MessageUpdateRequest represents request for editing a message
+type MessageUpdateRequest struct {
+	Content string `json:"content" binding:"required,min=1,max=1000"`
 }
 
 // MessageResponse represents message data in API responses
 type MessageResponse struct {
-	ID         int       `json:"id"`
-	SenderID   int       `json:"sender_id"`
-	ReceiverID int       `json:"receiver_id"`
-	Content    string    `json:"content"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID             int               `json:"id"`
+	SenderID       int               `json:"sender_id"`
+	ReceiverID     int               `json:"receiver_id,omitempty"`
+	ConversationID int               `json:"conversation_id,omitempty"`
+	Content        string            `json:"content"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	EditedAt       *time.Time        `json:"edited_at,omitempty"`
+	DeletedAt      *time.Time        `json:"deleted_at,omitempty"`
+	Status         string            `json:"status"`
+	ReplyToID      int               `json:"reply_to_id,omitempty"`
+	ReplyTo        *MessagePreview   `json:"reply_to,omitempty"`
+	Reactions      []ReactionSummary `json:"reactions,omitempty"`
 }
 
 // MessageWithUserResponse represents message with sender/receiver info
+// Receiver is nil for group messages, Reactions are aggregated for the requesting user
+// ReplyTo is preview of the quoted message
 type MessageWithUserResponse struct {
-	ID        int          `json:"id"`
-	Content   string       `json:"content"`
-	CreatedAt time.Time    `json:"created_at"`
-	Sender    UserResponse `json:"sender"`
-	Receiver  UserResponse `json:"receiver"`
+	ID             int               `json:"id"`
+	ConversationID int               `json:"conversation_id,omitempty"`
+	Content        string            `json:"content"`
+	CreatedAt      time.Time         `json:"created_at"`
+	EditedAt       *time.Time        `json:"edited_at,omitempty"`
+	DeletedAt      *time.Time        `json:"deleted_at,omitempty"`
+	DeliveredAt    *time.Time        `json:"delivered_at,omitempty"`
+	ReadAt         *time.Time        `json:"read_at,omitempty"`
+	Status         string            `json:"status"`
+	Sender         UserResponse      `json:"sender"`
+	Receiver       *UserResponse     `json:"receiver,omitempty"`
+	Reactions      []ReactionSummary `json:"reactions,omitempty"`
+	ReplyToID      int               `json:"reply_to_id,omitempty"`
+	ReplyTo        *MessagePreview   `json:"reply_to,omitempty"`
 }
 
 // MessageHistoryResponse represents chat history between two users
@@ -42,47 +95,211 @@ type MessageHistoryResponse struct {
 	Participant UserResponse              `json:"participant"`
 }
 
-// KafkaMessageEvent represents event published to Kafka
-type KafkaMessageEvent struct {
-	EventType  string    `json:"event_type"`
-	MessageID  int       `json:"message_id"`
+// MessageSyncResponse represents messages missed since client's last seen message
+// Updated holds current state of older messages edited, deleted or reacted to meanwhile;
+// Refetch is set instead when too many of them changed and the client should reload its history
+type MessageSyncResponse struct {
+	Messages []MessageWithUserResponse `json:"messages"`
+	Updated  []MessageWithUserResponse `json:"updated"`
+	HasMore  bool                      `json:"has_more"`
+	Refetch  bool                      `json:"refetch,omitempty"`
+}
+
+// ReceiptResponse represents delivery or read confirmation sent back to message sender
+// UserID is empty in delivery receipts of group messages, which are marked delivered once for all members
+type ReceiptResponse struct {
+	MessageIDs []int     `json:"message_ids"`
 	SenderID   int       `json:"sender_id"`
-	ReceiverID int       `json:"receiver_id"`
-	Content    string    `json:"content"`
+	UserID     int       `json:"user_id,omitempty"`
+	Status     string    `json:"status"`
 	Timestamp  time.Time `json:"timestamp"`
 }
 
+// Kafka event types
+const (
+	EventTypeMessageSent    = "message.sent"
+	EventTypeMessageEdited  = "message.edited"
+	EventTypeMessageDeleted = "message.deleted"
+)
+
+// KafkaMessageEvent represents event published to Kafka
+type KafkaMessageEvent struct {
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	MessageID      int             `json:"message_id"`
+	SenderID       int             `json:"sender_id"`
+	ReceiverID     int             `json:"receiver_id,omitempty"`
+	ConversationID int             `json:"conversation_id,omitempty"`
+	Content        string          `json:"content"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Timestamp      time.Time       `json:"timestamp"`
+	ReplyToID      int             `json:"reply_to_id,omitempty"`
+	ReplyTo        *MessagePreview `json:"reply_to,omitempty"`
+}
+
 // ToResponse converts Message to MessageResponse
+// Content of messages deleted for everyone is not exposed
 func (m *Message) ToResponse() MessageResponse {
+	content := m.Content
+	if m.IsDeleted() {
+		content = ""
+	}
+
 	return MessageResponse{
-		ID:         m.ID,
+		ID:             m.ID,
+		SenderID:       m.SenderID,
+		ReceiverID:     m.ReceiverID,
+		ConversationID: m.ConversationID,
+		Content:        content,
+		CreatedAt:      m.CreatedAt,
+		EditedAt:       m.EditedAt,
+		DeletedAt:      m.DeletedAt,
+		Status:         MessageStatus(m.DeliveredAt, m.ReadAt),
+		ReplyToID:      m.ReplyToID,
+		ReplyTo:        m.ReplyTo,
+	}
+}
+
+// ToMessageResponse converts MessageWithUserResponse to MessageResponse
+func (m *MessageWithUserResponse) ToMessageResponse() MessageResponse {
+	resp := MessageResponse{
+		ID:             m.ID,
+		SenderID:       m.Sender.ID,
+		ConversationID: m.ConversationID,
+		Content:        m.Content,
+		CreatedAt:      m.CreatedAt,
+		EditedAt:       m.EditedAt,
+		DeletedAt:      m.DeletedAt,
+		Status:         m.Status,
+		ReplyToID:      m.ReplyToID,
+		ReplyTo:        m.ReplyTo,
+		Reactions:      m.Reactions,
+	}
+	if m.Receiver != nil {
+		resp.ReceiverID = m.Receiver.ID
+	}
+	return resp
+}
+
+// ToKafkaEvent converts Message to KafkaMessageEvent of given type
+func (m *Message) ToKafkaEvent(eventType string) KafkaMessageEvent {
+	resp := m.ToResponse()
+	return resp.ToKafkaEvent(eventType)
+}
+
+// DeliveryReceipt returns receipt telling sender that the message reached recipientID
+// Group messages are marked delivered once for all members, so their receipts name no recipient
+func (m *MessageResponse) DeliveryReceipt(recipientID int, deliveredAt time.Time) *ReceiptResponse {
+	receipt := &ReceiptResponse{
+		MessageIDs: []int{m.ID},
 		SenderID:   m.SenderID,
-		ReceiverID: m.ReceiverID,
-		Content:    m.Content,
-		CreatedAt:  m.CreatedAt,
+		Status:     StatusDelivered,
+		Timestamp:  deliveredAt,
+	}
+	if m.ConversationID == 0 {
+		receipt.UserID = recipientID
 	}
+	return receipt
 }
 
-// ToKafkaEvent converts Message to KafkaMessageEvent
-func (m *Message) ToKafkaEvent() KafkaMessageEvent {
+// ToKafkaEvent converts MessageResponse to KafkaMessageEvent of given type
+// EventID is deterministic, so consumers can use it as idempotency key
+func (m *MessageResponse) ToKafkaEvent(eventType string) KafkaMessageEvent {
+	timestamp := m.CreatedAt
+	switch {
+	case m.DeletedAt != nil:
+		timestamp = *m.DeletedAt
+	case m.EditedAt != nil:
+		timestamp = *m.EditedAt
+	}
+
 	return KafkaMessageEvent{
-		EventType:  "message.sent",
-		MessageID:  m.ID,
-		SenderID:   m.SenderID,
-		ReceiverID: m.ReceiverID,
-		Content:    m.Content,
-		Timestamp:  m.CreatedAt,
+		EventID:        fmt.Sprintf("%s:%d:%d", eventType, m.ID, timestamp.UnixNano()),
+		EventType:      eventType,
+		MessageID:      m.ID,
+		SenderID:       m.SenderID,
+		ReceiverID:     m.ReceiverID,
+		ConversationID: m.ConversationID,
+		Content:        m.Content,
+		CreatedAt:      m.CreatedAt,
+		Timestamp:      timestamp,
+		ReplyToID:      m.ReplyToID,
+		ReplyTo:        m.ReplyTo,
 	}
 }
 
+// ToMessageResponse converts KafkaMessageEvent back to MessageResponse
+func (e *KafkaMessageEvent) ToMessageResponse() MessageResponse {
+	resp := MessageResponse{
+		ID:             e.MessageID,
+		SenderID:       e.SenderID,
+		ReceiverID:     e.ReceiverID,
+		ConversationID: e.ConversationID,
+		Content:        e.Content,
+		CreatedAt:      e.CreatedAt,
+		Status:         StatusSent,
+		ReplyToID:      e.ReplyToID,
+		ReplyTo:        e.ReplyTo,
+	}
+
+	timestamp := e.Timestamp
+	switch e.EventType {
+	case EventTypeMessageEdited:
+		resp.EditedAt = &timestamp
+	case EventTypeMessageDeleted:
+		resp.DeletedAt = &timestamp
+	}
+
+	return resp
+}
+
+// PartitionKey returns key that keeps events of one conversation in order
+func (e *KafkaMessageEvent) PartitionKey() string {
+	if e.ConversationID != 0 {
+		return fmt.Sprintf("conversation:%d", e.ConversationID)
+	}
+
+	low, high := e.SenderID, e.ReceiverID
+	if low > high {
+		low, high = high, low
+	}
+	return fmt.Sprintf("direct:%d:%d", low, high)
+}
+
 // CreateMessageFromRequest creates Message from MessageCreateRequest
 func CreateMessageFromRequest(req MessageCreateRequest, senderId int) *Message {
 	return &Message{
-		SenderID:   senderId,
-		ReceiverID: req.ReceiverID,
-		Content:    req.Content,
-		CreatedAt:  time.Now(),
+		SenderID:       senderId,
+		ReceiverID:     req.ReceiverID,
+		ConversationID: req.ConversationID,
+		ReplyToID:      req.ReplyToID,
+		Content:        req.Content,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// MessageStatus derives delivery status from delivery and read timestamps
+func MessageStatus(deliveredAt, readAt *time.Time) string {
+	switch {
+	case readAt != nil:
+		return StatusRead
+	case deliveredAt != nil:
+		return StatusDelivered
+	default:
+		return StatusSent
+	}
+}
+
+// PreviewLength is the maximum number of characters in message preview
+const PreviewLength = 100
+
+// TruncatePreview shortens message content for previews
+func TruncatePreview(content string) string {
+	runes := []rune(content)
+	if len(runes) <= PreviewLength {
+		return content
 	}
+	return string(runes[:PreviewLength]) + "…"
 }
 
 // IsValidMessageContent checks if message content meets requirements
@@ -102,7 +319,44 @@ func IsValidMessageContent(content string) bool {
 	return len(trimmed) > 0
 }
 
-// GetChatParticipants returns IDs of chat participants
+// IsDeleted reports whether message was deleted for everyone
+func (m *Message) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
+
+// IsGroupMessage reports whether message belongs to a group chat
+func (m *Message) IsGroupMessage() bool {
+	return m.ConversationID != 0
+}
+
+// GetChatParticipants returns IDs of direct chat participants
+// For group messages use conversation members instead
 func (m *Message) GetChatParticipants() []int {
 	return []int{m.SenderID, m.ReceiverID}
 }
+
+// InSameChat reports whether both messages belong to the same group or the same direct chat
+func (m *Message) InSameChat(other *Message) bool {
+	if m.IsGroupMessage() || other.IsGroupMessage() {
+		return m.ConversationID == other.ConversationID
+	}
+
+	return (m.SenderID == other.SenderID && m.ReceiverID == other.ReceiverID) ||
+		(m.SenderID == other.ReceiverID && m.ReceiverID == other.SenderID)
+}
+
+// ToPreview converts Message to MessagePreview used for quotes
+// Content of messages deleted for everyone is not exposed
+func (m *Message) ToPreview(senderUsername string) *MessagePreview {
+	preview := &MessagePreview{
+		ID:             m.ID,
+		SenderID:       m.SenderID,
+		SenderUsername: senderUsername,
+		CreatedAt:      m.CreatedAt,
+		Deleted:        m.IsDeleted(),
+	}
+	if !preview.Deleted {
+		preview.Content = TruncatePreview(m.Content)
+	}
+	return preview
+}
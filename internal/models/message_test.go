@@ -0,0 +1,52 @@
+package models
+
+import "testing"
+
+func TestPartitionKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		event KafkaMessageEvent
+		want  string
+	}{
+		{"direct from lower ID", KafkaMessageEvent{SenderID: 1, ReceiverID: 2}, "direct:1:2"},
+		{"direct from higher ID", KafkaMessageEvent{SenderID: 2, ReceiverID: 1}, "direct:1:2"},
+		{"group", KafkaMessageEvent{SenderID: 1, ConversationID: 7}, "conversation:7"},
+		{"group ignores sender", KafkaMessageEvent{SenderID: 3, ConversationID: 7}, "conversation:7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.PartitionKey(); got != tt.want {
+				t.Errorf("PartitionKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKafkaEventIDIsDeterministic(t *testing.T) {
+	message := &Message{ID: 10, SenderID: 1, ReceiverID: 2, Content: "hi"}
+
+	first := message.ToKafkaEvent(EventTypeMessageSent)
+	second := message.ToKafkaEvent(EventTypeMessageSent)
+	if first.EventID != second.EventID {
+		t.Errorf("EventID changed between conversions: %q and %q", first.EventID, second.EventID)
+	}
+
+	edited := message.ToKafkaEvent(EventTypeMessageEdited)
+	if edited.EventID == first.EventID {
+		t.Errorf("events of different types share EventID %q", first.EventID)
+	}
+}
+
+func TestKafkaEventRoundTrip(t *testing.T) {
+	message := &Message{ID: 10, SenderID: 1, ConversationID: 7, ReplyToID: 4, Content: "hi"}
+	event := message.ToKafkaEvent(EventTypeMessageEdited)
+
+	resp := event.ToMessageResponse()
+	if resp.ID != message.ID || resp.ConversationID != message.ConversationID || resp.ReplyToID != message.ReplyToID {
+		t.Errorf("ToMessageResponse() = %+v, lost fields of %+v", resp, message)
+	}
+	if resp.EditedAt == nil || !resp.EditedAt.Equal(event.Timestamp) {
+		t.Errorf("EditedAt = %v, want %v", resp.EditedAt, event.Timestamp)
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// OutboxEntry represents an event stored in the outbox, waiting to be published
+type OutboxEntry struct {
+	ID             int64     `json:"id" db:"id"`
+	IdempotencyKey string    `json:"idempotency_key" db:"idempotency_key"`
+	EventType      string    `json:"event_type" db:"event_type"`
+	PartitionKey   string    `json:"partition_key" db:"partition_key"`
+	Payload        []byte    `json:"payload" db:"payload"`
+	Attempts       int       `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
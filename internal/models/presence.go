@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Presence states
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+// Presence represents user's presence as seen by other users
+// Away is the stored flag, State combines it with whether user is connected
+type Presence struct {
+	UserID     int        `json:"user_id"`
+	State      string     `json:"state"`
+	StatusText string     `json:"status_text,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Away       bool       `json:"-"`
+}
+
+// PresenceUpdateRequest represents request for changing own presence
+// Omitted fields keep their current values, empty status_text clears it
+type PresenceUpdateRequest struct {
+	State      string  `json:"state" binding:"omitempty,oneof=online away"`
+	StatusText *string `json:"status_text" binding:"omitempty,max=100"`
+}
+
+// PresenceListResponse represents presence of several users in API responses
+type PresenceListResponse struct {
+	Presence []Presence `json:"presence"`
+}
+
+// Resolve sets State from whether user has live connections
+func (p *Presence) Resolve(online bool) {
+	switch {
+	case !online:
+		p.State = PresenceOffline
+	case p.Away:
+		p.State = PresenceAway
+	default:
+		p.State = PresenceOnline
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxEmojiLength is the maximum number of characters in a reaction, enough for ZWJ sequences
+const MaxEmojiLength = 16
+
+// ReactionRequest represents request for adding or removing a reaction
+type ReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required,max=32"`
+}
+
+// ReactionSummary represents aggregated reactions of one emoji on a message
+// Reacted tells whether the viewing user is among those who reacted
+type ReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+// ReactionResponse represents reaction change sent to message participants
+// SenderID and ReceiverID belong to the message, UserID is who reacted
+type ReactionResponse struct {
+	MessageID      int       `json:"message_id"`
+	ConversationID int       `json:"conversation_id,omitempty"`
+	SenderID       int       `json:"sender_id"`
+	ReceiverID     int       `json:"receiver_id,omitempty"`
+	UserID         int       `json:"user_id"`
+	Emoji          string    `json:"emoji"`
+	Count          int       `json:"count"`
+	Removed        bool      `json:"removed,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// IsValidEmoji checks that reaction is a short symbol sequence, not arbitrary text
+func IsValidEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > 32 || utf8.RuneCountInString(emoji) > MaxEmojiLength {
+		return false
+	}
+
+	hasSymbol := false
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || unicode.IsLetter(r) {
+			return false
+		}
+		if r >= utf8.RuneSelf {
+			hasSymbol = true
+		}
+	}
+
+	return hasSymbol
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// TokenTypeBearer is the token type returned to clients
+const TokenTypeBearer = "Bearer"
+
+// RefreshToken represents a stored refresh token
+// Only SHA-256 hash of the token is stored; tokens issued by rotation share FamilyID
+type RefreshToken struct {
+	ID        int        `json:"id" db:"id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	FamilyID  string     `json:"family_id" db:"family_id"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// WSTicket represents a single-use ticket for authenticating a WebSocket upgrade
+// It carries identity of the access token it was issued for, so revocation still applies
+type WSTicket struct {
+	TicketHash     string    `json:"-" db:"ticket_hash"`
+	UserID         int       `json:"user_id" db:"user_id"`
+	Username       string    `json:"username" db:"username"`
+	TokenID        string    `json:"token_id" db:"token_id"`
+	TokenVersion   int       `json:"token_version" db:"token_version"`
+	TokenExpiresAt time.Time `json:"token_expires_at" db:"token_expires_at"`
+	ExpiresAt      time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// WSTicketResponse represents issued WebSocket ticket in API responses
+// ExpiresIn is lifetime of the ticket in seconds
+type WSTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+// RefreshRequest represents request for exchanging refresh token for a new token pair
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest represents request for ending a session
+// RefreshToken is optional; when given, its token family is revoked too
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse represents issued token pair in API responses
+// ExpiresIn is lifetime of the access token in seconds
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// LoginResponse represents successful login in API responses
+type LoginResponse struct {
+	User UserResponse `json:"user"`
+	TokenResponse
+}
+
+// IsExpired checks if refresh token is past its expiration time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -26,10 +26,12 @@ type UserLoginRequest struct {
 }
 
 // UserResponse represents user data in API responses
+// Presence is filled on user lookups
 type UserResponse struct {
 	ID        int       `json:"id"`
 	Username  string    `json:"username"`
 	CreatedAt time.Time `json:"created_at"`
+	Presence  *Presence `json:"presence,omitempty"`
 }
 
 // UserListResponse represents list of users in API responses
@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// SetupAdminRouter initializes handler of the internal admin listener
+// Metrics expose runtime details, so it must not be served on the public address
+func SetupAdminRouter() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
@@ -2,6 +2,7 @@ package routers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/squ1ky/talkify/internal/config"
 	"github.com/squ1ky/talkify/internal/handlers"
 	"github.com/squ1ky/talkify/internal/middleware"
 	"github.com/squ1ky/talkify/internal/services"
@@ -9,30 +10,32 @@ import (
 )
 
 // SetupRouter initializes gin.Engine with routes and middleware
-func SetupRouter(cfgSecret string, userService *services.UserService, messageService *services.MessageService) *gin.Engine {
+func SetupRouter(cfg *config.Config, userService *services.UserService, messageService *services.MessageService, conversationService *services.ConversationService, presenceService *services.PresenceService, authService *services.AuthService, keys *services.KeySet, hub *websocket.Hub) *gin.Engine {
 	r := gin.Default()
 
-	jwtService := services.NewJWTService(cfgSecret)
+	origins := middleware.NewOriginPolicy(cfg.Server.AllowedOrigins)
+	r.Use(middleware.CORS(origins))
 
-	hub := websocket.NewHub(messageService)
-	go hub.Run()
+	userHandler := handlers.NewUserHandler(userService, authService, presenceService, hub)
+	messageHandler := handlers.NewMessageHandler(messageService, userService, hub)
+	conversationHandler := handlers.NewConversationHandler(conversationService)
+	wsHandler := handlers.NewWebSocketHandler(hub, authService, origins, cfg.WebSocket)
+	jwksHandler := handlers.NewJWKSHandler(keys)
 
-	userHandler := handlers.NewUserHandler(userService, jwtService)
-	messageHandler := handlers.NewMessageHandler(messageService, userService)
-	wsHandler := handlers.NewWebSocketHandler(hub, userService)
+	jwksHandler.RegisterRoutes(r)
 
 	apiV1 := r.Group("/api/v1")
 
 	userHandler.RegisterPublicRoutes(apiV1)
+	wsHandler.RegisterRoutes(apiV1)
 
 	auth := apiV1.Group("/")
-	auth.Use(middleware.JWTMiddleware(cfgSecret))
+	auth.Use(middleware.JWTMiddleware(authService))
 
 	userHandler.RegisterProtectedRoutes(auth)
 	messageHandler.RegisterProtectedRoutes(auth)
-	wsHandler.RegisterRoutes(auth)
-
-	auth.GET("/online-users", wsHandler.GetOnlineUsers)
+	conversationHandler.RegisterProtectedRoutes(auth)
+	wsHandler.RegisterProtectedRoutes(auth)
 
 	return r
 }
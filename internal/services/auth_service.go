@@ -0,0 +1,277 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/squ1ky/talkify/internal/database"
+	"github.com/squ1ky/talkify/internal/models"
+	"log"
+	"time"
+)
+
+// wsTicketTTL is how long a WebSocket ticket can be redeemed after issuing
+const wsTicketTTL = 30 * time.Second
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrInvalidTicket       = errors.New("invalid or expired ticket")
+)
+
+// TicketRedeemer exchanges single-use WebSocket tickets for claims of the access token they were issued for
+type TicketRedeemer interface {
+	RedeemTicket(ticket string) (*JWTClaims, error)
+}
+
+// AuthService issues access and refresh token pairs
+// Refresh tokens are single-use: every refresh rotates the token, and presenting
+// an already rotated token revokes the whole family issued from the same login
+type AuthService struct {
+	jwt              *JWTService
+	refreshTokens    *database.RefreshTokenRepository
+	revocations      *database.RevocationRepository
+	tickets          *database.TicketRepository
+	users            *database.UserRepository
+	refreshExpiresIn time.Duration
+}
+
+// NewAuthService creates new auth service
+func NewAuthService(jwtService *JWTService, refreshTokens *database.RefreshTokenRepository, revocations *database.RevocationRepository, tickets *database.TicketRepository, users *database.UserRepository, refreshExpiresIn time.Duration) *AuthService {
+	return &AuthService{
+		jwt:              jwtService,
+		refreshTokens:    refreshTokens,
+		revocations:      revocations,
+		tickets:          tickets,
+		users:            users,
+		refreshExpiresIn: refreshExpiresIn,
+	}
+}
+
+// IssueTokens starts a new token family for the user, e.g. on login
+func (s *AuthService) IssueTokens(user *models.User) (*models.TokenResponse, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, stored, err := s.newRefreshToken(user.ID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokens.Create(stored); err != nil {
+		return nil, err
+	}
+
+	return s.tokenResponse(user, refreshToken)
+}
+
+// Refresh exchanges refresh token for a new token pair of the same family
+func (s *AuthService) Refresh(rawToken string) (*models.TokenResponse, error) {
+	current, err := s.refreshTokens.GetByHash(hashToken(rawToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if current.RevokedAt != nil || current.IsExpired(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.RotatedAt != nil {
+		return nil, s.revokeReused(current)
+	}
+
+	user, err := s.users.GetByID(current.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	refreshToken, next, err := s.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := s.refreshTokens.Rotate(current.ID, next)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Token was used by a concurrent request in the meantime
+		return nil, s.revokeReused(current)
+	}
+
+	return s.tokenResponse(user, refreshToken)
+}
+
+// Logout revokes the access token and, if given, the refresh token family of the session
+func (s *AuthService) Logout(userID int, tokenID string, expiresAt time.Time, refreshToken string) error {
+	if err := s.revocations.RevokeToken(tokenID, userID, expiresAt); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	token, err := s.refreshTokens.GetByHash(hashToken(refreshToken))
+	if err != nil || token.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+
+	return s.refreshTokens.RevokeFamily(token.FamilyID, time.Now())
+}
+
+// LogoutAll revokes every access and refresh token of the user
+func (s *AuthService) LogoutAll(userID int) error {
+	if err := s.revocations.IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+
+	return s.refreshTokens.RevokeUser(userID, time.Now())
+}
+
+// ValidateToken validates access token and checks that it was not revoked by logout
+func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
+	claims, err := s.jwt.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.revocations.IsRevoked(claims.UserID, claims.ID, claims.TokenVersion)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// IssueTicket creates single-use WebSocket ticket bound to the access token of the request
+// Browsers can't set Authorization header on WebSocket upgrade, so they pass the ticket instead
+func (s *AuthService) IssueTicket(claims *JWTClaims) (*models.WSTicketResponse, error) {
+	value, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ticket := &models.WSTicket{
+		TicketHash:     hashToken(value),
+		UserID:         claims.UserID,
+		Username:       claims.Username,
+		TokenID:        claims.ID,
+		TokenVersion:   claims.TokenVersion,
+		TokenExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:      now.Add(wsTicketTTL),
+		CreatedAt:      now,
+	}
+
+	if err := s.tickets.Create(ticket); err != nil {
+		return nil, err
+	}
+
+	return &models.WSTicketResponse{
+		Ticket:    value,
+		ExpiresIn: int64(wsTicketTTL.Seconds()),
+	}, nil
+}
+
+// RedeemTicket consumes ticket and returns claims of the access token it was issued for
+func (s *AuthService) RedeemTicket(value string) (*JWTClaims, error) {
+	ticket, err := s.tickets.Consume(hashToken(value))
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+
+	now := time.Now()
+	if !now.Before(ticket.ExpiresAt) || !now.Before(ticket.TokenExpiresAt) {
+		return nil, ErrInvalidTicket
+	}
+
+	revoked, err := s.revocations.IsRevoked(ticket.UserID, ticket.TokenID, ticket.TokenVersion)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return &JWTClaims{
+		UserID:       ticket.UserID,
+		Username:     ticket.Username,
+		TokenVersion: ticket.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        ticket.TokenID,
+			ExpiresAt: jwt.NewNumericDate(ticket.TokenExpiresAt),
+		},
+	}, nil
+}
+
+// revokeReused revokes token family after reuse of a rotated token
+func (s *AuthService) revokeReused(token *models.RefreshToken) error {
+	log.Printf("Refresh token reuse detected for user %d, revoking token family", token.UserID)
+
+	if err := s.refreshTokens.RevokeFamily(token.FamilyID, time.Now()); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// newRefreshToken generates refresh token value and its stored representation
+func (s *AuthService) newRefreshToken(userID int, familyID string) (string, *models.RefreshToken, error) {
+	value, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	return value, &models.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(value),
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(s.refreshExpiresIn),
+		CreatedAt: now,
+	}, nil
+}
+
+// tokenResponse signs access token and builds TokenResponse
+func (s *AuthService) tokenResponse(user *models.User, refreshToken string) (*models.TokenResponse, error) {
+	tokenVersion, err := s.revocations.GetTokenVersion(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.jwt.GenerateToken(user.ID, user.Username, tokenVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	return &models.TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    models.TokenTypeBearer,
+		ExpiresIn:    int64(s.jwt.ExpiresIn().Seconds()),
+	}, nil
+}
+
+// randomToken returns URL-safe random string of n random bytes
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns hex-encoded SHA-256 of token value
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
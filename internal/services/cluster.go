@@ -0,0 +1,247 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/squ1ky/talkify/internal/config"
+	"github.com/squ1ky/talkify/internal/database"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// maxNotificationSize is Postgres limit for NOTIFY payload in bytes
+	maxNotificationSize = 8000
+
+	// listenerPingInterval is how often an idle listener checks its connection
+	listenerPingInterval = 90 * time.Second
+)
+
+var (
+	ErrClusterClosed        = errors.New("cluster bus closed")
+	ErrNotificationTooLarge = errors.New("cluster notification exceeds payload limit")
+)
+
+// ClusterNotification is sent between talkify instances
+// Payload format depends on Kind and is defined by the sender
+type ClusterNotification struct {
+	NodeID  string          `json:"node_id"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ClusterBus delivers notifications between talkify instances and tracks cluster-wide presence
+type ClusterBus interface {
+	// Publish sends notification to all other instances
+	Publish(kind string, payload interface{}) error
+	// Listen calls handler for notifications of other instances until bus is closed
+	Listen(handler func(ClusterNotification)) error
+	SetOnline(userID int, online bool) error
+	OnlineUsers() ([]int, error)
+	IsOnline(userID int) (bool, error)
+	Close() error
+}
+
+// PostgresCluster is ClusterBus built on Postgres LISTEN/NOTIFY
+// Presence of every node is stored in the database and expires with node's heartbeat
+// Local presence is also kept in memory, so it can be restored if other nodes remove this one as stale
+type PostgresCluster struct {
+	nodeID    string
+	cluster   *database.ClusterRepository
+	listener  *pq.Listener
+	config    config.ClusterConfig
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.Mutex
+	online map[int]bool
+}
+
+// NewPostgresCluster registers this instance as cluster node and subscribes to the cluster channel
+// Node ID defaults to <hostname>-<pid> when not configured
+func NewPostgresCluster(cluster *database.ClusterRepository, dsn string, cfg config.ClusterConfig) (*PostgresCluster, error) {
+	nodeID := cfg.NodeID
+	if nodeID == "" {
+		hostname, _ := os.Hostname()
+		nodeID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	// Drop presence left by a previous run with the same node ID
+	if err := cluster.RemoveNode(nodeID); err != nil {
+		return nil, err
+	}
+	if _, err := cluster.Heartbeat(nodeID); err != nil {
+		return nil, err
+	}
+
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Cluster listener error: %v", err)
+		}
+	})
+	if err := listener.Listen(cfg.Channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on cluster channel: %w", err)
+	}
+
+	c := &PostgresCluster{
+		nodeID:   nodeID,
+		cluster:  cluster,
+		listener: listener,
+		config:   cfg,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		online:   make(map[int]bool),
+	}
+	go c.heartbeat()
+
+	log.Printf("Joined cluster as node %s", nodeID)
+	return c, nil
+}
+
+// heartbeat keeps this node alive and removes nodes that stopped sending heartbeats
+func (c *PostgresCluster) heartbeat() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			rejoined, err := c.cluster.Heartbeat(c.nodeID)
+			if err != nil {
+				log.Printf("Cluster heartbeat error: %v", err)
+			} else if rejoined {
+				c.restorePresence()
+			}
+			if removed, err := c.cluster.RemoveStaleNodes(c.config.NodeTTL); err != nil {
+				log.Printf("Failed to remove stale cluster nodes: %v", err)
+			} else if removed > 0 {
+				log.Printf("Removed %d stale cluster nodes", removed)
+			}
+		}
+	}
+}
+
+// Publish sends notification to all other nodes
+func (c *PostgresCluster) Publish(kind string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster payload: %w", err)
+	}
+
+	notification, err := json.Marshal(ClusterNotification{
+		NodeID:  c.nodeID,
+		Kind:    kind,
+		Payload: data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster notification: %w", err)
+	}
+
+	if len(notification) > maxNotificationSize {
+		return ErrNotificationTooLarge
+	}
+
+	return c.cluster.Notify(c.config.Channel, string(notification))
+}
+
+// Listen passes notifications of other nodes to handler until Close is called
+// Notifications sent while the connection was being re-established are lost
+func (c *PostgresCluster) Listen(handler func(ClusterNotification)) error {
+	for {
+		select {
+		case <-c.stop:
+			return ErrClusterClosed
+
+		case n, ok := <-c.listener.Notify:
+			if !ok {
+				return ErrClusterClosed
+			}
+			if n == nil {
+				log.Println("Cluster listener reconnected, notifications may have been missed")
+				continue
+			}
+
+			var notification ClusterNotification
+			if err := json.Unmarshal([]byte(n.Extra), &notification); err != nil {
+				log.Printf("Skipping malformed cluster notification: %v", err)
+				continue
+			}
+			if notification.NodeID == c.nodeID {
+				continue
+			}
+			handler(notification)
+
+		case <-time.After(listenerPingInterval):
+			go c.listener.Ping()
+		}
+	}
+}
+
+// restorePresence records local presence again after this node was removed as stale with its presence
+func (c *PostgresCluster) restorePresence() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	userIDs := make([]int, 0, len(c.online))
+	for userID := range c.online {
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := c.cluster.SetOnlineMany(c.nodeID, userIDs); err != nil {
+		log.Printf("Failed to restore presence of %d users after rejoining cluster: %v", len(userIDs), err)
+		return
+	}
+	log.Printf("Rejoined cluster as node %s, restored presence of %d users", c.nodeID, len(userIDs))
+}
+
+// SetOnline records whether user has connections on this node
+// Local state is updated even if the database write fails, so restoring presence after rejoin includes it
+func (c *PostgresCluster) SetOnline(userID int, online bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if online {
+		c.online[userID] = true
+		return c.cluster.SetOnline(c.nodeID, userID)
+	}
+
+	delete(c.online, userID)
+	return c.cluster.SetOffline(c.nodeID, userID)
+}
+
+// OnlineUsers returns users connected to any live node
+func (c *PostgresCluster) OnlineUsers() ([]int, error) {
+	return c.cluster.GetOnlineUserIDs(c.config.NodeTTL)
+}
+
+// IsOnline checks if user is connected to any live node
+func (c *PostgresCluster) IsOnline(userID int) (bool, error) {
+	return c.cluster.IsUserOnline(userID, c.config.NodeTTL)
+}
+
+// Close stops listening and removes this node with its presence from the cluster
+func (c *PostgresCluster) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+
+		if removeErr := c.cluster.RemoveNode(c.nodeID); removeErr != nil {
+			err = removeErr
+		}
+		if closeErr := c.listener.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
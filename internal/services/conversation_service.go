@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"github.com/squ1ky/talkify/internal/database"
+	"github.com/squ1ky/talkify/internal/models"
+)
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrNotMember            = errors.New("user is not a member of the conversation")
+	ErrAlreadyMember        = errors.New("user is already a member of the conversation")
+	ErrInsufficientRole     = errors.New("insufficient role for this action")
+)
+
+// ConversationService manages group conversation business logic
+type ConversationService struct {
+	conversations *database.ConversationRepository
+	messages      *database.MessageRepository
+	users         *database.UserRepository
+	reactions     *database.ReactionRepository
+}
+
+// NewConversationService creates new conversation service
+func NewConversationService(conversations *database.ConversationRepository, messages *database.MessageRepository, users *database.UserRepository, reactions *database.ReactionRepository) *ConversationService {
+	return &ConversationService{
+		conversations: conversations,
+		messages:      messages,
+		users:         users,
+		reactions:     reactions,
+	}
+}
+
+// Create creates new group conversation owned by creator
+func (s *ConversationService) Create(creatorID int, req models.ConversationCreateRequest) (*models.ConversationResponse, error) {
+	memberIDs := make([]int, 0, len(req.MemberIDs))
+	seen := map[int]bool{creatorID: true}
+	for _, id := range req.MemberIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if _, err := s.users.GetByID(id); err != nil {
+			return nil, ErrNotFound
+		}
+		memberIDs = append(memberIDs, id)
+	}
+
+	conversation := models.CreateConversationFromRequest(req, creatorID)
+	if err := s.conversations.Create(conversation, memberIDs); err != nil {
+		return nil, err
+	}
+
+	return s.withMembers(conversation)
+}
+
+// Get returns conversation with its members, visible only to members
+func (s *ConversationService) Get(userID, conversationID int) (*models.ConversationResponse, error) {
+	conversation, err := s.conversations.GetByID(conversationID)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	if _, err := s.requireMember(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.withMembers(conversation)
+}
+
+// ListForUser returns all conversations the user is a member of
+func (s *ConversationService) ListForUser(userID int) ([]models.ConversationResponse, error) {
+	return s.conversations.ListByUser(userID)
+}
+
+// Rename changes conversation name, allowed for owner and admins
+func (s *ConversationService) Rename(userID, conversationID int, req models.ConversationUpdateRequest) (*models.ConversationResponse, error) {
+	member, err := s.requireMember(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.CanManageMembers(member.Role) {
+		return nil, ErrInsufficientRole
+	}
+
+	if err := s.conversations.UpdateName(conversationID, req.Name); err != nil {
+		return nil, err
+	}
+
+	return s.Get(userID, conversationID)
+}
+
+// AddMember adds user to conversation, only owner can add admins
+func (s *ConversationService) AddMember(actorID, conversationID int, req models.ConversationMemberAddRequest) (*models.ConversationResponse, error) {
+	actor, err := s.requireMember(conversationID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	role := req.Role
+	if role == "" {
+		role = models.RoleMember
+	}
+	if !models.CanManageMembers(actor.Role) || (role == models.RoleAdmin && actor.Role != models.RoleOwner) {
+		return nil, ErrInsufficientRole
+	}
+
+	if _, err := s.users.GetByID(req.UserID); err != nil {
+		return nil, ErrNotFound
+	}
+	if _, err := s.conversations.GetMember(conversationID, req.UserID); err == nil {
+		return nil, ErrAlreadyMember
+	}
+
+	if err := s.conversations.AddMember(conversationID, req.UserID, role); err != nil {
+		return nil, err
+	}
+
+	return s.Get(actorID, conversationID)
+}
+
+// UpdateMemberRole promotes or demotes a member, allowed only for owner
+func (s *ConversationService) UpdateMemberRole(actorID, conversationID, targetID int, req models.ConversationMemberRoleRequest) (*models.ConversationResponse, error) {
+	actor, err := s.requireMember(conversationID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Role != models.RoleOwner || actorID == targetID {
+		return nil, ErrInsufficientRole
+	}
+
+	if _, err := s.conversations.GetMember(conversationID, targetID); err != nil {
+		return nil, ErrNotMember
+	}
+
+	if err := s.conversations.UpdateMemberRole(conversationID, targetID, req.Role); err != nil {
+		return nil, err
+	}
+
+	return s.Get(actorID, conversationID)
+}
+
+// RemoveMember removes another member from conversation according to roles
+func (s *ConversationService) RemoveMember(actorID, conversationID, targetID int) error {
+	if actorID == targetID {
+		return s.Leave(actorID, conversationID)
+	}
+
+	actor, err := s.requireMember(conversationID, actorID)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.conversations.GetMember(conversationID, targetID)
+	if err != nil {
+		return ErrNotMember
+	}
+
+	if !models.CanRemoveMember(actor.Role, target.Role) {
+		return ErrInsufficientRole
+	}
+
+	return s.conversations.RemoveMember(conversationID, targetID)
+}
+
+// Leave removes user from conversation
+// If the owner leaves, ownership passes to the oldest admin or member;
+// the conversation is deleted when its last member leaves
+func (s *ConversationService) Leave(userID, conversationID int) error {
+	if _, err := s.requireMember(conversationID, userID); err != nil {
+		return err
+	}
+
+	left, err := s.conversations.Leave(conversationID, userID)
+	if err != nil {
+		return err
+	}
+	if !left {
+		return ErrNotMember
+	}
+
+	return nil
+}
+
+// GetHistory returns messages of a group conversation with pagination
+func (s *ConversationService) GetHistory(userID, conversationID, limit, offset int) ([]models.MessageWithUserResponse, int, error) {
+	if _, err := s.requireMember(conversationID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	messages, err := s.messages.GetGroupHistory(conversationID, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := attachReactions(s.reactions, messages, userID); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := s.messages.CountGroupMessages(conversationID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, count, nil
+}
+
+// requireMember returns user's membership or ErrNotMember
+func (s *ConversationService) requireMember(conversationID, userID int) (*models.ConversationMember, error) {
+	member, err := s.conversations.GetMember(conversationID, userID)
+	if err != nil {
+		return nil, ErrNotMember
+	}
+	return member, nil
+}
+
+// withMembers builds ConversationResponse with member list
+func (s *ConversationService) withMembers(conversation *models.Conversation) (*models.ConversationResponse, error) {
+	members, err := s.conversations.GetMembers(conversation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := conversation.ToResponse()
+	resp.Members = members
+	return &resp, nil
+}
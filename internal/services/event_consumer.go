@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/squ1ky/talkify/internal/config"
+	"github.com/squ1ky/talkify/internal/models"
+	"log"
+	"os"
+	"sync"
+)
+
+// ErrConsumerClosed is returned by Consume after consumer was closed
+var ErrConsumerClosed = errors.New("event consumer closed")
+
+// EventConsumer reads message events published by any talkify instance
+type EventConsumer interface {
+	// Consume calls handler for every event until consumer is closed
+	Consume(handler func(models.KafkaMessageEvent)) error
+	Close() error
+}
+
+// NewEventConsumer creates Kafka consumer if Kafka is enabled, nil otherwise
+func NewEventConsumer(cfg config.KafkaConfig) EventConsumer {
+	if !cfg.Enabled {
+		return nil
+	}
+	return NewKafkaConsumer(cfg)
+}
+
+// KafkaConsumer reads events from a Kafka topic
+// Every instance must use its own consumer group so that each one receives all events
+type KafkaConsumer struct {
+	reader *kafka.Reader
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewKafkaConsumer creates consumer reading configured topic from the newest offset
+// Group ID defaults to talkify-<hostname> when not configured
+func NewKafkaConsumer(cfg config.KafkaConfig) *KafkaConsumer {
+	groupID := cfg.ConsumerGroup
+	if groupID == "" {
+		hostname, _ := os.Hostname()
+		groupID = "talkify-" + hostname
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &KafkaConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     cfg.Brokers,
+			Topic:       cfg.Topic,
+			GroupID:     groupID,
+			StartOffset: kafka.LastOffset,
+		}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Consume reads events and passes them to handler, committing offsets after handling
+func (c *KafkaConsumer) Consume(handler func(models.KafkaMessageEvent)) error {
+	for {
+		msg, err := c.reader.FetchMessage(c.ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return ErrConsumerClosed
+			}
+			return fmt.Errorf("failed to fetch kafka message: %w", err)
+		}
+
+		var event models.KafkaMessageEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			log.Printf("Skipping malformed event at offset %d: %v", msg.Offset, err)
+		} else {
+			handler(event)
+		}
+
+		if err := c.reader.CommitMessages(c.ctx, msg); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("Failed to commit kafka offset %d: %v", msg.Offset, err)
+		}
+	}
+}
+
+// Close stops consuming and leaves the consumer group
+func (c *KafkaConsumer) Close() error {
+	c.cancel()
+	return c.reader.Close()
+}
+
+// MemoryBroker is an in-memory stand-in for Kafka, useful for tests without a broker
+// Every subscriber receives every published event, like instances with own consumer groups
+type MemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[*MemoryConsumer]bool
+}
+
+// NewMemoryBroker creates broker without subscribers
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: make(map[*MemoryConsumer]bool)}
+}
+
+// Publish fans events out to all current subscribers
+func (b *MemoryBroker) Publish(events ...models.KafkaMessageEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for consumer := range b.subscribers {
+		for _, event := range events {
+			consumer.deliver(event)
+		}
+	}
+	return nil
+}
+
+// Close does nothing, subscribers are closed separately
+func (b *MemoryBroker) Close() error {
+	return nil
+}
+
+// Subscribe creates consumer receiving events published from now on
+func (b *MemoryBroker) Subscribe() *MemoryConsumer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	consumer := &MemoryConsumer{
+		broker: b,
+		events: make(chan models.KafkaMessageEvent, 256),
+		done:   make(chan struct{}),
+	}
+	b.subscribers[consumer] = true
+	return consumer
+}
+
+// unsubscribe removes consumer from broker
+func (b *MemoryBroker) unsubscribe(consumer *MemoryConsumer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscribers, consumer)
+}
+
+// MemoryConsumer receives events from MemoryBroker
+type MemoryConsumer struct {
+	broker    *MemoryBroker
+	events    chan models.KafkaMessageEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// deliver queues event, blocking like a lagging consumer until there is room or consumer is closed
+func (c *MemoryConsumer) deliver(event models.KafkaMessageEvent) {
+	select {
+	case c.events <- event:
+	case <-c.done:
+	}
+}
+
+// Consume passes queued events to handler until consumer is closed
+func (c *MemoryConsumer) Consume(handler func(models.KafkaMessageEvent)) error {
+	for {
+		select {
+		case event := <-c.events:
+			handler(event)
+		case <-c.done:
+			return ErrConsumerClosed
+		}
+	}
+}
+
+// Close unsubscribes consumer from broker and stops Consume
+func (c *MemoryConsumer) Close() error {
+	c.closeOnce.Do(func() {
+		c.broker.unsubscribe(c)
+		close(c.done)
+	})
+	return nil
+}
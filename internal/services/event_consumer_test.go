@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"github.com/squ1ky/talkify/internal/models"
+	"testing"
+	"time"
+)
+
+func TestMemoryPublisherKeepsOrder(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	for i := 1; i <= 3; i++ {
+		if err := publisher.Publish(models.KafkaMessageEvent{EventID: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	events := publisher.Events()
+	if len(events) != 3 {
+		t.Fatalf("Events() returned %d events, want 3", len(events))
+	}
+	for i, event := range events {
+		if want := fmt.Sprint(i + 1); event.EventID != want {
+			t.Errorf("event %d has ID %q, want %q", i, event.EventID, want)
+		}
+	}
+
+	events[0].EventID = "changed"
+	if publisher.Events()[0].EventID != "1" {
+		t.Error("Events() returned slice sharing memory with publisher")
+	}
+}
+
+func TestMemoryBrokerDeliversToEverySubscriberInOrder(t *testing.T) {
+	broker := NewMemoryBroker()
+	consumers := []*MemoryConsumer{broker.Subscribe(), broker.Subscribe()}
+
+	received := make([]chan models.KafkaMessageEvent, len(consumers))
+	stopped := make([]chan error, len(consumers))
+	for i, consumer := range consumers {
+		received[i] = make(chan models.KafkaMessageEvent, 10)
+		stopped[i] = make(chan error, 1)
+		go func(events chan models.KafkaMessageEvent, done chan error) {
+			done <- consumer.Consume(func(event models.KafkaMessageEvent) {
+				events <- event
+			})
+		}(received[i], stopped[i])
+	}
+
+	for i := 1; i <= 3; i++ {
+		broker.Publish(models.KafkaMessageEvent{EventID: fmt.Sprint(i), ConversationID: 7})
+	}
+
+	for i := range consumers {
+		for want := 1; want <= 3; want++ {
+			select {
+			case event := <-received[i]:
+				if event.EventID != fmt.Sprint(want) {
+					t.Errorf("consumer %d got event %q, want %q", i, event.EventID, fmt.Sprint(want))
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("consumer %d did not receive event %d", i, want)
+			}
+		}
+	}
+
+	for i, consumer := range consumers {
+		consumer.Close()
+		select {
+		case err := <-stopped[i]:
+			if !errors.Is(err, ErrConsumerClosed) {
+				t.Errorf("Consume() error = %v, want ErrConsumerClosed", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("consumer %d did not stop after Close", i)
+		}
+	}
+}
+
+func TestMemoryBrokerSkipsClosedConsumers(t *testing.T) {
+	broker := NewMemoryBroker()
+	consumer := broker.Subscribe()
+	consumer.Close()
+	consumer.Close()
+
+	done := make(chan struct{})
+	go func() {
+		broker.Publish(models.KafkaMessageEvent{EventID: "1"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on closed consumer")
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/squ1ky/talkify/internal/config"
+	"github.com/squ1ky/talkify/internal/models"
+	"sync"
+	"time"
+)
+
+// kafkaBatchTimeout limits how long Kafka writer waits to fill a batch before sending it
+const kafkaBatchTimeout = 10 * time.Millisecond
+
+// EventPublisher publishes message events to external systems
+// Publish sends events in order; if only some of them fail, the error is PublishErrors
+type EventPublisher interface {
+	Publish(events ...models.KafkaMessageEvent) error
+	Close() error
+}
+
+// PublishErrors holds result of every event of a partially failed Publish, nil for published events
+type PublishErrors []error
+
+// Error summarizes failed events
+func (e PublishErrors) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("failed to publish %d of %d events: %v", failed, len(e), first)
+}
+
+// NewEventPublisher creates Kafka publisher if Kafka is enabled, no-op publisher otherwise
+func NewEventPublisher(cfg config.KafkaConfig) EventPublisher {
+	if !cfg.Enabled {
+		return NoopPublisher{}
+	}
+	return NewKafkaPublisher(cfg)
+}
+
+// NoopPublisher discards all events
+type NoopPublisher struct{}
+
+// Publish does nothing
+func (NoopPublisher) Publish(...models.KafkaMessageEvent) error {
+	return nil
+}
+
+// Close does nothing
+func (NoopPublisher) Close() error {
+	return nil
+}
+
+// MemoryPublisher keeps published events in memory, useful for tests without a broker
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []models.KafkaMessageEvent
+}
+
+// NewMemoryPublisher creates empty in-memory publisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish stores events in memory
+func (p *MemoryPublisher) Publish(events ...models.KafkaMessageEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// Events returns copy of all published events in publish order
+func (p *MemoryPublisher) Events() []models.KafkaMessageEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := make([]models.KafkaMessageEvent, len(p.events))
+	copy(events, p.events)
+	return events
+}
+
+// Close does nothing
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
+
+// KafkaPublisher writes events as JSON to a Kafka topic
+// Events are keyed by conversation so that their order is kept per conversation
+type KafkaPublisher struct {
+	writer  *kafka.Writer
+	timeout time.Duration
+}
+
+// NewKafkaPublisher creates publisher writing to configured brokers and topic
+func NewKafkaPublisher(cfg config.KafkaConfig) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: kafkaBatchTimeout,
+		},
+		timeout: 10 * time.Second,
+	}
+}
+
+// Publish writes events to Kafka in a single batch and waits for acknowledgement
+// EventID is sent as idempotency-key header for deduplication by consumers
+func (p *KafkaPublisher) Publish(events ...models.KafkaMessageEvent) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+
+		messages = append(messages, kafka.Message{
+			Key:   []byte(event.PartitionKey()),
+			Value: value,
+			Time:  event.Timestamp,
+			Headers: []kafka.Header{
+				{Key: "idempotency-key", Value: []byte(event.EventID)},
+			},
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	err := p.writer.WriteMessages(ctx, messages...)
+	if err == nil {
+		return nil
+	}
+
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) && len(writeErrs) == len(events) {
+		errs := make(PublishErrors, len(writeErrs))
+		for i, writeErr := range writeErrs {
+			if writeErr != nil {
+				errs[i] = fmt.Errorf("failed to publish event to kafka: %w", writeErr)
+			}
+		}
+		return errs
+	}
+
+	return fmt.Errorf("failed to publish events to kafka: %w", err)
+}
+
+// Close flushes pending writes and closes connection to Kafka
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
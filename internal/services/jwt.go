@@ -2,59 +2,93 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/squ1ky/talkify/internal/config"
 	"time"
 )
 
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenRevoked = errors.New("token has been revoked")
+)
+
+// TokenValidator validates access tokens and returns their claims
+// Returns error wrapping ErrInvalidToken or ErrTokenRevoked for rejected tokens
+type TokenValidator interface {
+	ValidateToken(tokenString string) (*JWTClaims, error)
+}
+
 // JWTService handles token generation and validation
 type JWTService struct {
-	secretKey string
+	keys   *KeySet
+	config config.JWTConfig
 }
 
 // JWTClaims defines the payload stored in the token
+// TokenVersion must match user's current version, bumping it revokes all issued tokens
 type JWTClaims struct {
-	UserID   int    `json:"user_id"`
-	Username string `json:"username"`
+	UserID       int    `json:"user_id"`
+	Username     string `json:"username"`
+	TokenVersion int    `json:"ver"`
 	jwt.RegisteredClaims
 }
 
 // NewJWTService creates a new JWTService instance
-func NewJWTService(secretKey string) *JWTService {
-	return &JWTService{secretKey: secretKey}
+func NewJWTService(keys *KeySet, cfg config.JWTConfig) *JWTService {
+	return &JWTService{keys: keys, config: cfg}
 }
 
-// GenerateToken issues a signed JWT for the given user
-func (j *JWTService) GenerateToken(userID int, username string) (string, error) {
+// ExpiresIn returns lifetime of issued tokens
+func (j *JWTService) ExpiresIn() time.Duration {
+	return j.config.ExpiresIn
+}
+
+// GenerateToken issues a signed JWT with unique ID for the given user
+func (j *JWTService) GenerateToken(userID int, username string, tokenVersion int) (string, error) {
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
 	claims := JWTClaims{
-		UserID:   userID,
-		Username: username,
+		UserID:       userID,
+		Username:     username,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.config.ExpiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "talkify",
+			Issuer:    j.config.Issuer,
+			Audience:  jwt.ClaimStrings{j.config.Audience},
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.keys.Sign(claims)
 }
 
-// ValidateToken parses and validates an incoming JWT
+// ValidateToken parses an incoming JWT and checks signature, expiry, issuer and audience
+// Revocation is not checked here, see AuthService.ValidateToken
 func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&JWTClaims{},
+		j.keys.Keyfunc,
+		jwt.WithValidMethods(j.keys.Algorithms()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(j.config.Issuer),
+		jwt.WithAudience(j.config.Audience),
+		jwt.WithLeeway(j.config.Leeway),
+	)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid || claims.UserID <= 0 {
+		return nil, ErrInvalidToken
 	}
 
-	return nil, errors.New("invalid token")
+	return claims, nil
 }
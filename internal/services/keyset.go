@@ -0,0 +1,279 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/squ1ky/talkify/internal/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Signing algorithms supported by KeySet
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+const (
+	privateKeySuffix = ".pem"
+	publicKeySuffix  = ".pub.pem"
+)
+
+var (
+	ErrUnknownKeyID        = errors.New("unknown signing key ID")
+	ErrUnexpectedAlgorithm = errors.New("unexpected signing method")
+)
+
+// SigningKey is a key of the KeySet identified by kid
+// Keys without private part can only verify tokens
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	private   interface{}
+	public    interface{}
+}
+
+// KeySet holds keys for signing and verifying tokens
+// Tokens are signed with the active key; every key of the set verifies tokens carrying its kid,
+// so keys can be rotated without invalidating tokens that are already issued
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+	legacy *SigningKey
+}
+
+// JWK represents public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS represents JSON Web Key Set served to other services
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeySet builds key set from JWT config
+// JWT_SECRET becomes HS256 key JWT_SECRET_KEY_ID and also verifies tokens issued without kid.
+// JWT_KEYS_DIR may hold RSA or Ed25519 keys: <kid>.pem private keys sign and verify,
+// <kid>.pub.pem public keys only verify
+func LoadKeySet(cfg config.JWTConfig) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+
+	if cfg.Secret != "" {
+		secret := &SigningKey{
+			ID:        cfg.SecretKeyID,
+			Algorithm: AlgorithmHS256,
+			private:   []byte(cfg.Secret),
+			public:    []byte(cfg.Secret),
+		}
+		ks.keys[secret.ID] = secret
+		ks.legacy = secret
+	}
+
+	if cfg.KeysDir != "" {
+		if err := ks.loadDir(cfg.KeysDir); err != nil {
+			return nil, err
+		}
+	}
+
+	activeID := cfg.ActiveKeyID
+	if activeID == "" {
+		activeID = cfg.SecretKeyID
+	}
+
+	active, ok := ks.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found", activeID)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("active signing key %q has no private key", activeID)
+	}
+	ks.active = active
+
+	return ks, nil
+}
+
+// loadDir loads PEM keys from directory
+func (ks *KeySet) loadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read keys directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, privateKeySuffix) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read key %s: %w", name, err)
+		}
+
+		var key *SigningKey
+		if kid, ok := strings.CutSuffix(name, publicKeySuffix); ok {
+			key, err = parsePublicKey(kid, data)
+		} else {
+			key, err = parsePrivateKey(strings.TrimSuffix(name, privateKeySuffix), data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse key %s: %w", name, err)
+		}
+
+		if existing, ok := ks.keys[key.ID]; ok && existing.private != nil {
+			// Private key already provides the public part
+			continue
+		}
+		ks.keys[key.ID] = key
+	}
+
+	return nil
+}
+
+// parsePrivateKey parses PKCS#8 or PKCS#1 encoded RSA or Ed25519 private key
+func parsePrivateKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+
+	key, err := newPublicKey(kid, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.private = signer
+	return key, nil
+}
+
+// parsePublicKey parses PKIX encoded RSA or Ed25519 public key
+func parsePublicKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPublicKey(kid, parsed)
+}
+
+// newPublicKey creates verification-only key choosing algorithm by key type
+func newPublicKey(kid string, public interface{}) (*SigningKey, error) {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return &SigningKey{ID: kid, Algorithm: AlgorithmRS256, public: public}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{ID: kid, Algorithm: AlgorithmEdDSA, public: public}, nil
+	default:
+		return nil, errors.New("unsupported public key type")
+	}
+}
+
+// Sign signs claims with the active key and sets kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.active.Algorithm), claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.private)
+}
+
+// Keyfunc returns verification key for token by its kid header
+// Tokens without kid are verified with JWT_SECRET, as issued before key rotation support
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	key := ks.legacy
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = ks.keys[kid]
+	}
+	if key == nil {
+		return nil, ErrUnknownKeyID
+	}
+
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrUnexpectedAlgorithm
+	}
+	return key.public, nil
+}
+
+// Algorithms returns algorithms of all keys in the set
+func (ks *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	var algorithms []string
+	for _, key := range ks.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algorithms = append(algorithms, key.Algorithm)
+		}
+	}
+
+	sort.Strings(algorithms)
+	return algorithms
+}
+
+// JWKS returns public keys of the set; HMAC keys are secret and never exposed
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Algorithm: key.Algorithm,
+				Use:       "sig",
+				N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Algorithm: key.Algorithm,
+				Use:       "sig",
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+	return jwks
+}
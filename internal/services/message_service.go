@@ -8,34 +8,59 @@ import (
 )
 
 var (
-	ErrInvalidContent = errors.New("invalid message content")
-	ErrMsgNotFound    = errors.New("message not found")
+	ErrInvalidContent   = errors.New("invalid message content")
+	ErrMsgNotFound      = errors.New("message not found")
+	ErrInvalidRecipient = errors.New("exactly one of receiver_id or conversation_id is required")
+	ErrNotSender        = errors.New("only the sender can modify this message")
+	ErrInvalidScope     = errors.New("scope must be either 'me' or 'everyone'")
+	ErrInvalidEmoji     = errors.New("reaction must be a single emoji")
+	ErrInvalidReply     = errors.New("reply_to_id must reference a message in the same conversation")
 )
 
 // MessageService manages message-related business logic
 type MessageService struct {
-	messages *database.MessageRepository
-	users    *database.UserRepository
+	messages      *database.MessageRepository
+	users         *database.UserRepository
+	conversations *database.ConversationRepository
+	reactions     *database.ReactionRepository
 }
 
 // NewMessageService creates new message service
-func NewMessageService(messages *database.MessageRepository, users *database.UserRepository) *MessageService {
+func NewMessageService(messages *database.MessageRepository, users *database.UserRepository, conversations *database.ConversationRepository, reactions *database.ReactionRepository) *MessageService {
 	return &MessageService{
-		messages: messages,
-		users:    users,
+		messages:      messages,
+		users:         users,
+		conversations: conversations,
+		reactions:     reactions,
 	}
 }
 
-// SendMessage creates new message between two users
+// SendMessage creates new message to a user or to a group conversation
 func (s *MessageService) SendMessage(senderID int, req models.MessageCreateRequest) (*models.MessageResponse, error) {
 	if !models.IsValidMessageContent(req.Content) {
 		return nil, ErrInvalidContent
 	}
-	if _, err := s.users.GetByID(req.ReceiverID); err != nil {
+	if (req.ReceiverID == 0) == (req.ConversationID == 0) {
+		return nil, ErrInvalidRecipient
+	}
+
+	if req.ConversationID != 0 {
+		if _, err := s.conversations.GetMember(req.ConversationID, senderID); err != nil {
+			return nil, ErrNotMember
+		}
+	} else if _, err := s.users.GetByID(req.ReceiverID); err != nil {
 		return nil, errors.New("receiver not found")
 	}
 
 	message := models.CreateMessageFromRequest(req, senderID)
+	if message.ReplyToID != 0 {
+		quoted, err := s.messages.GetByID(message.ReplyToID)
+		if err != nil || quoted.IsDeleted() || !quoted.InSameChat(message) {
+			return nil, ErrInvalidReply
+		}
+		s.setReply(message, quoted)
+	}
+
 	if err := s.messages.Create(message); err != nil {
 		return nil, err
 	}
@@ -44,12 +69,283 @@ func (s *MessageService) SendMessage(senderID int, req models.MessageCreateReque
 	return &resp, nil
 }
 
+// EditMessage changes content of a message, allowed only for its sender
+func (s *MessageService) EditMessage(userID, messageID int, req models.MessageUpdateRequest) (*models.MessageResponse, error) {
+	if !models.IsValidMessageContent(req.Content) {
+		return nil, ErrInvalidContent
+	}
+
+	message, err := s.messages.GetByID(messageID)
+	if err != nil || message.IsDeleted() {
+		return nil, ErrMsgNotFound
+	}
+	if message.SenderID != userID {
+		return nil, ErrNotSender
+	}
+
+	s.loadReply(message)
+	if err := s.messages.UpdateContent(message, req.Content, time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp := message.ToResponse()
+	return &resp, nil
+}
+
+// GetMessage returns message by ID as it is currently stored
+func (s *MessageService) GetMessage(messageID int) (*models.MessageResponse, error) {
+	message, err := s.messages.GetByID(messageID)
+	if err != nil {
+		return nil, ErrMsgNotFound
+	}
+
+	s.loadReply(message)
+	resp := message.ToResponse()
+	return &resp, nil
+}
+
+// loadReply sets preview of the message quoted by message, if any
+func (s *MessageService) loadReply(message *models.Message) {
+	if message.ReplyToID == 0 {
+		return
+	}
+
+	quoted, err := s.messages.GetByID(message.ReplyToID)
+	if err != nil {
+		return
+	}
+	s.setReply(message, quoted)
+}
+
+// setReply sets preview of quoted message, left empty if its sender can't be loaded
+func (s *MessageService) setReply(message, quoted *models.Message) {
+	sender, err := s.users.GetByID(quoted.SenderID)
+	if err != nil {
+		return
+	}
+	message.ReplyTo = quoted.ToPreview(sender.Username)
+}
+
+// DeleteMessage hides message for the user (scope "me") or replaces it
+// with a tombstone for all participants (scope "everyone", sender only)
+func (s *MessageService) DeleteMessage(userID, messageID int, scope string) (*models.MessageResponse, error) {
+	if scope != models.DeleteScopeMe && scope != models.DeleteScopeEveryone {
+		return nil, ErrInvalidScope
+	}
+
+	message, err := s.messages.GetByID(messageID)
+	if err != nil {
+		return nil, ErrMsgNotFound
+	}
+	if !s.isParticipant(message, userID) {
+		return nil, ErrMsgNotFound
+	}
+
+	if scope == models.DeleteScopeMe {
+		if err := s.messages.Hide(messageID, userID); err != nil {
+			return nil, err
+		}
+	} else {
+		if message.SenderID != userID {
+			return nil, ErrNotSender
+		}
+		if message.IsDeleted() {
+			return nil, ErrMsgNotFound
+		}
+		if err := s.messages.SoftDelete(message, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := message.ToResponse()
+	return &resp, nil
+}
+
+// React adds user's emoji reaction to a message they can see
+// Returns reaction change for participants or nil if user already reacted with this emoji
+func (s *MessageService) React(userID, messageID int, emoji string) (*models.ReactionResponse, error) {
+	message, err := s.reactionTarget(userID, messageID, emoji)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := s.reactions.Add(messageID, userID, emoji)
+	if err != nil || !added {
+		return nil, err
+	}
+
+	return s.reactionChange(message, userID, emoji, false)
+}
+
+// Unreact removes user's emoji reaction from a message
+// Returns reaction change for participants or nil if there was no such reaction
+func (s *MessageService) Unreact(userID, messageID int, emoji string) (*models.ReactionResponse, error) {
+	message, err := s.reactionTarget(userID, messageID, emoji)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.reactions.Remove(messageID, userID, emoji)
+	if err != nil || !removed {
+		return nil, err
+	}
+
+	return s.reactionChange(message, userID, emoji, true)
+}
+
+// reactionTarget validates emoji and returns message the user may react to
+func (s *MessageService) reactionTarget(userID, messageID int, emoji string) (*models.Message, error) {
+	if !models.IsValidEmoji(emoji) {
+		return nil, ErrInvalidEmoji
+	}
+
+	message, err := s.messages.GetByID(messageID)
+	if err != nil || message.IsDeleted() || !s.isParticipant(message, userID) {
+		return nil, ErrMsgNotFound
+	}
+
+	return message, nil
+}
+
+// reactionChange builds reaction change with current number of reactions with the emoji
+func (s *MessageService) reactionChange(message *models.Message, userID int, emoji string, removed bool) (*models.ReactionResponse, error) {
+	count, err := s.reactions.Count(message.ID, emoji)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReactionResponse{
+		MessageID:      message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		ReceiverID:     message.ReceiverID,
+		UserID:         userID,
+		Emoji:          emoji,
+		Count:          count,
+		Removed:        removed,
+		Timestamp:      time.Now(),
+	}, nil
+}
+
+// MarkDelivered records first delivery of a message to a recipient
+// Returns receipt for the sender or nil if message was already delivered
+func (s *MessageService) MarkDelivered(message *models.MessageResponse, recipientID int) (*models.ReceiptResponse, error) {
+	now := time.Now()
+	updated, err := s.messages.MarkDelivered(message.ID, now)
+	if err != nil || !updated {
+		return nil, err
+	}
+
+	return message.DeliveryReceipt(recipientID, now), nil
+}
+
+// MarkRead marks every message of the conversation up to messageID as read by the user
+// Returns receipts for senders of affected messages
+func (s *MessageService) MarkRead(userID, messageID int) ([]models.ReceiptResponse, error) {
+	message, err := s.messages.GetByID(messageID)
+	if err != nil || !s.isParticipant(message, userID) {
+		return nil, ErrMsgNotFound
+	}
+
+	now := time.Now()
+	bySender := make(map[int][]int)
+
+	if message.IsGroupMessage() {
+		bySender, err = s.messages.MarkGroupRead(userID, message.ConversationID, messageID, now)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.messages.UpdateReadCursor(userID, message.ConversationID, messageID); err != nil {
+			return nil, err
+		}
+	} else {
+		otherID := message.SenderID
+		if otherID == userID {
+			otherID = message.ReceiverID
+		}
+
+		ids, err := s.messages.MarkDirectRead(userID, otherID, messageID, now)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			bySender[otherID] = ids
+		}
+	}
+
+	receipts := make([]models.ReceiptResponse, 0, len(bySender))
+	for senderID, ids := range bySender {
+		receipts = append(receipts, models.ReceiptResponse{
+			MessageIDs: ids,
+			SenderID:   senderID,
+			UserID:     userID,
+			Status:     models.StatusRead,
+			Timestamp:  now,
+		})
+	}
+
+	return receipts, nil
+}
+
+// isParticipant checks whether user can see the message
+func (s *MessageService) isParticipant(message *models.Message, userID int) bool {
+	if message.IsGroupMessage() {
+		_, err := s.conversations.GetMember(message.ConversationID, userID)
+		return err == nil
+	}
+	return message.SenderID == userID || message.ReceiverID == userID
+}
+
+// GetParticipants returns IDs of users who should receive the message:
+// both sides of a direct chat or all members of a group
+func (s *MessageService) GetParticipants(message *models.MessageResponse) ([]int, error) {
+	if message.ConversationID != 0 {
+		return s.conversations.GetMemberIDs(message.ConversationID)
+	}
+	return []int{message.SenderID, message.ReceiverID}, nil
+}
+
+// GetRecipients returns IDs of users the sender talks to in a direct chat or group,
+// excluding the sender; group membership of the sender is verified
+func (s *MessageService) GetRecipients(senderID, receiverID, conversationID int) ([]int, error) {
+	if (receiverID == 0) == (conversationID == 0) {
+		return nil, ErrInvalidRecipient
+	}
+	if conversationID == 0 {
+		return []int{receiverID}, nil
+	}
+
+	memberIDs, err := s.conversations.GetMemberIDs(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]int, 0, len(memberIDs))
+	isMember := false
+	for _, id := range memberIDs {
+		if id == senderID {
+			isMember = true
+			continue
+		}
+		recipients = append(recipients, id)
+	}
+
+	if !isMember {
+		return nil, ErrNotMember
+	}
+
+	return recipients, nil
+}
+
 // GetConversationHistory returns list of messages between two users with pagination
 func (s *MessageService) GetConversationHistory(userID1, userID2, limit, offset int) ([]models.MessageWithUserResponse, int, error) {
 	messages, err := s.messages.GetConversationHistory(userID1, userID2, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
+	if err := attachReactions(s.reactions, messages, userID1); err != nil {
+		return nil, 0, err
+	}
 
 	count, err := s.messages.CountConversationMessages(userID1, userID2)
 	if err != nil {
@@ -59,12 +355,68 @@ func (s *MessageService) GetConversationHistory(userID1, userID2, limit, offset
 	return messages, count, nil
 }
 
-// GetRecentConversations returns user's list of recent interlocutors
-func (s *MessageService) GetRecentConversations(userID, limit int) ([]models.UserResponse, error) {
+// GetRecentConversations returns user's conversation list with last message previews and unread counters
+func (s *MessageService) GetRecentConversations(userID, limit int) ([]models.ConversationSummaryResponse, error) {
 	return s.messages.GetRecentConversations(userID, limit)
 }
 
-// GetMessagesSince allows to get new messages after some time
-func (s *MessageService) GetMessagesSince(userID int, since time.Time) ([]models.MessageWithUserResponse, error) {
-	return s.messages.GetMessagesSince(userID, since)
+// GetMessagesSince allows to get new messages after some time or after message with sinceID
+// Returns at most limit messages, oldest first, and whether more are available,
+// along with older messages changed since then
+func (s *MessageService) GetMessagesSince(userID int, since time.Time, sinceID, limit int) (*models.MessageSyncResponse, error) {
+	messages, err := s.messages.GetMessagesSince(userID, since, sinceID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.messages.GetMessagesUpdatedSince(userID, since, sinceID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.MessageSyncResponse{
+		Messages: messages,
+		Updated:  updated,
+		HasMore:  len(messages) > limit,
+		Refetch:  len(updated) > limit,
+	}
+	if resp.HasMore {
+		resp.Messages = messages[:limit]
+	}
+	if resp.Refetch || resp.Updated == nil {
+		resp.Updated = []models.MessageWithUserResponse{}
+	}
+	if resp.Messages == nil {
+		resp.Messages = []models.MessageWithUserResponse{}
+	}
+
+	if err := attachReactions(s.reactions, resp.Messages, userID); err != nil {
+		return nil, err
+	}
+	if err := attachReactions(s.reactions, resp.Updated, userID); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// attachReactions fills aggregated reactions of messages as seen by viewerID
+func attachReactions(reactions *database.ReactionRepository, messages []models.MessageWithUserResponse, viewerID int) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	messageIDs := make([]int, 0, len(messages))
+	for _, m := range messages {
+		messageIDs = append(messageIDs, m.ID)
+	}
+
+	summaries, err := reactions.GetSummaries(messageIDs, viewerID)
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		messages[i].Reactions = summaries[messages[i].ID]
+	}
+	return nil
 }
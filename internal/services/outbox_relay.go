@@ -0,0 +1,214 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/squ1ky/talkify/internal/config"
+	"github.com/squ1ky/talkify/internal/database"
+	"github.com/squ1ky/talkify/internal/models"
+	"log"
+	"time"
+)
+
+const (
+	// maxRetryBackoff caps delay between publish attempts of one outbox entry
+	maxRetryBackoff = 5 * time.Minute
+
+	// pruneInterval is how often published and failed entries older than retention are deleted
+	pruneInterval = time.Hour
+
+	// pruneBatchSize caps entries deleted by one statement, so pruning doesn't hold long row locks
+	pruneBatchSize = 1000
+)
+
+// OutboxRelay drains the outbox table to the event publisher in the background
+// Events sharing a partition key (one conversation) are published strictly in order:
+// when an event fails, later events of the same conversation wait for its retry
+// and may be published again, consumers deduplicate them by event ID
+type OutboxRelay struct {
+	outbox    *database.OutboxRepository
+	publisher EventPublisher
+	config    config.OutboxConfig
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewOutboxRelay creates new outbox relay
+func NewOutboxRelay(outbox *database.OutboxRepository, publisher EventPublisher, cfg config.OutboxConfig) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    outbox,
+		publisher: publisher,
+		config:    cfg,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Run polls the outbox until Stop is called
+// This should be called in a goroutine: go relay.Run()
+func (r *OutboxRelay) Run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.relayBatch(); err != nil {
+				log.Printf("Outbox relay error: %v", err)
+			}
+		case <-pruneTicker.C:
+			if err := r.prune(); err != nil {
+				log.Printf("Outbox prune error: %v", err)
+			}
+		}
+	}
+}
+
+// Stop stops the relay and waits for the current batch to finish
+func (r *OutboxRelay) Stop() {
+	close(r.stop)
+	<-r.done
+}
+
+// relayBatch publishes due entries of one batch with a single Publish call
+// The outbox lock is held meanwhile, but no transaction is kept open during network I/O
+func (r *OutboxRelay) relayBatch() error {
+	lock, err := r.outbox.Lock()
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		// Another relay is draining the outbox
+		return nil
+	}
+	defer lock.Unlock()
+
+	now := time.Now()
+	entries, err := r.outbox.GetDue(now, r.config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	// Partitions whose entry can't be decoded and waits for retry
+	blocked := make(map[string]bool)
+
+	var due []models.OutboxEntry
+	var events []models.KafkaMessageEvent
+	for _, entry := range entries {
+		if blocked[entry.PartitionKey] {
+			continue
+		}
+
+		var event models.KafkaMessageEvent
+		if err := json.Unmarshal(entry.Payload, &event); err != nil {
+			retry, markErr := r.markFailure(entry, fmt.Errorf("failed to decode outbox payload: %w", err), now)
+			if markErr != nil {
+				return markErr
+			}
+			blocked[entry.PartitionKey] = retry
+			continue
+		}
+
+		due = append(due, entry)
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	errs := eventErrors(r.publisher.Publish(events...), len(events))
+
+	var published []int64
+	failed := make(map[string]bool)
+	for i, entry := range due {
+		if failed[entry.PartitionKey] {
+			// An earlier event of the conversation failed, this one stays pending and is published again after it
+			continue
+		}
+		if errs[i] == nil {
+			published = append(published, entry.ID)
+			continue
+		}
+
+		retry, err := r.markFailure(entry, errs[i], now)
+		if err != nil {
+			return err
+		}
+		failed[entry.PartitionKey] = retry
+	}
+
+	return r.outbox.MarkPublished(published)
+}
+
+// prune deletes published and failed entries older than the configured retention
+// Runs under the outbox lock, so only one instance prunes at a time
+func (r *OutboxRelay) prune() error {
+	lock, err := r.outbox.Lock()
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return nil
+	}
+	defer lock.Unlock()
+
+	olderThan := time.Now().Add(-r.config.Retention)
+	for {
+		deleted, err := r.outbox.Prune(olderThan, pruneBatchSize)
+		if err != nil {
+			return err
+		}
+		if deleted < pruneBatchSize {
+			return nil
+		}
+
+		select {
+		case <-r.stop:
+			return nil
+		default:
+		}
+	}
+}
+
+// markFailure schedules retry of failed entry or gives up on it after MaxAttempts
+// Returns true if entry will be retried
+func (r *OutboxRelay) markFailure(entry models.OutboxEntry, cause error, now time.Time) (bool, error) {
+	if entry.Attempts+1 >= r.config.MaxAttempts {
+		log.Printf("Giving up on outbox entry %s after %d attempts: %v", entry.IdempotencyKey, entry.Attempts+1, cause)
+		return false, r.outbox.MarkFailed(entry.ID, cause)
+	}
+
+	return true, r.outbox.MarkRetry(entry.ID, now.Add(retryBackoff(entry.Attempts)), cause)
+}
+
+// eventErrors returns publish error of each of n events
+func eventErrors(err error, n int) []error {
+	var perEvent PublishErrors
+	if errors.As(err, &perEvent) && len(perEvent) == n {
+		return perEvent
+	}
+
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// retryBackoff returns exponential delay before the next publish attempt
+func retryBackoff(attempts int) time.Duration {
+	backoff := time.Second << attempts
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventErrors(t *testing.T) {
+	failure := errors.New("broker unavailable")
+
+	errs := eventErrors(failure, 3)
+	for i, err := range errs {
+		if err != failure {
+			t.Errorf("event %d error = %v, want %v", i, err, failure)
+		}
+	}
+
+	partial := PublishErrors{nil, failure, nil}
+	errs = eventErrors(partial, 3)
+	if errs[0] != nil || errs[1] != failure || errs[2] != nil {
+		t.Errorf("eventErrors() = %v, want per-event errors %v", errs, partial)
+	}
+
+	if errs := eventErrors(nil, 2); errs[0] != nil || errs[1] != nil {
+		t.Errorf("eventErrors(nil) = %v, want no errors", errs)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{3, 8 * time.Second},
+		{20, maxRetryBackoff},
+		{80, maxRetryBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := retryBackoff(tt.attempts); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"github.com/squ1ky/talkify/internal/database"
+	"github.com/squ1ky/talkify/internal/models"
+	"time"
+)
+
+var ErrInvalidPresence = errors.New("state must be either 'online' or 'away'")
+
+// PresenceService manages stored part of users' presence: away flag, custom status and last seen time
+// Whether user is connected is known to the WebSocket hub, which resolves the final state
+type PresenceService struct {
+	presence *database.PresenceRepository
+}
+
+// NewPresenceService creates new presence service
+func NewPresenceService(presence *database.PresenceRepository) *PresenceService {
+	return &PresenceService{presence: presence}
+}
+
+// Get returns stored presence of a user
+func (s *PresenceService) Get(userID int) (*models.Presence, error) {
+	presence, err := s.presence.GetByUserID(userID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return presence, nil
+}
+
+// GetMany returns stored presence of users, unknown IDs are skipped
+func (s *PresenceService) GetMany(userIDs []int) ([]models.Presence, error) {
+	return s.presence.GetByUserIDs(userIDs)
+}
+
+// Update changes away state and custom status of a user
+func (s *PresenceService) Update(userID int, req models.PresenceUpdateRequest) (*models.Presence, error) {
+	var away *bool
+	switch req.State {
+	case "":
+	case models.PresenceOnline, models.PresenceAway:
+		isAway := req.State == models.PresenceAway
+		away = &isAway
+	default:
+		return nil, ErrInvalidPresence
+	}
+
+	return s.presence.Update(userID, away, req.StatusText)
+}
+
+// MarkSeen records that user's last connection has just closed
+func (s *PresenceService) MarkSeen(userID int) (*models.Presence, error) {
+	return s.presence.SetLastSeen(userID, time.Now())
+}
+
+// GetContacts returns IDs of users subscribed to user's presence:
+// everyone sharing a direct chat or a group with them
+func (s *PresenceService) GetContacts(userID int) ([]int, error) {
+	return s.presence.GetContactIDs(userID)
+}
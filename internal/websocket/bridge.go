@@ -0,0 +1,90 @@
+package websocket
+
+import (
+	"errors"
+	"github.com/squ1ky/talkify/internal/models"
+	"github.com/squ1ky/talkify/internal/services"
+	"log"
+	"time"
+)
+
+const (
+	// deliveredTTL is how long Hub remembers delivered event IDs
+	// Must exceed the usual outbox relay lag, otherwise local events are delivered twice
+	deliveredTTL = 10 * time.Minute
+
+	// deliveredSweepInterval is how often Hub forgets expired event IDs
+	deliveredSweepInterval = time.Minute
+)
+
+// wsEventTypes maps message event types to WebSocket event types
+var wsEventTypes = map[string]string{
+	models.EventTypeMessageSent:    EventMessage,
+	models.EventTypeMessageEdited:  EventMessageEdited,
+	models.EventTypeMessageDeleted: EventMessageDeleted,
+}
+
+// eventTypes maps WebSocket event types back to message event types
+var eventTypes = map[string]string{
+	EventMessage:        models.EventTypeMessageSent,
+	EventMessageEdited:  models.EventTypeMessageEdited,
+	EventMessageDeleted: models.EventTypeMessageDeleted,
+}
+
+// ConsumeEvents delivers events published by any talkify instance to local clients
+// Blocks until consumer is closed, so it should be called in a goroutine
+func (h *Hub) ConsumeEvents(consumer services.EventConsumer) {
+	err := consumer.Consume(func(event models.KafkaMessageEvent) {
+		eventType, ok := wsEventTypes[event.EventType]
+		if !ok {
+			log.Printf("Skipping event %s of unknown type %q", event.EventID, event.EventType)
+			return
+		}
+
+		message := event.ToMessageResponse()
+		h.Events <- &MessageEvent{
+			Type:    eventType,
+			Message: &message,
+			EventID: event.EventID,
+			Remote:  true,
+		}
+	})
+
+	if err != nil && !errors.Is(err, services.ErrConsumerClosed) {
+		log.Printf("Event consumer stopped: %v", err)
+	}
+}
+
+// deliverEvent notifies participants unless the event was already delivered by this Hub
+// Events sent through this instance come back from the consumer, so they are remembered by ID
+// New messages are marked delivered when a recipient is online, local events are passed to the cluster
+// Group messages carry one delivery mark, so any online member marks them delivered for all
+func (h *Hub) deliverEvent(event *MessageEvent) {
+	eventID := event.EventID
+	if eventID == "" {
+		eventID = event.Message.ToKafkaEvent(eventTypes[event.Type]).EventID
+	}
+
+	if _, ok := h.delivered[eventID]; ok {
+		return
+	}
+	h.delivered[eventID] = time.Now().Add(deliveredTTL)
+
+	recipients := h.notifyParticipants(event.Type, event.Message)
+	if event.Type == EventMessage && len(recipients) > 0 {
+		h.markDelivered(event.Message, recipients[0])
+	}
+
+	if !event.Remote {
+		h.publishMessageEvent(eventID, event)
+	}
+}
+
+// expireDelivered forgets delivered event IDs older than deliveredTTL
+func (h *Hub) expireDelivered(now time.Time) {
+	for eventID, expiresAt := range h.delivered {
+		if now.After(expiresAt) {
+			delete(h.delivered, eventID)
+		}
+	}
+}
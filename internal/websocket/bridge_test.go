@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"github.com/squ1ky/talkify/internal/models"
+	"github.com/squ1ky/talkify/internal/services"
+	"testing"
+	"time"
+)
+
+// groupMessageService keeps group members and delivery marks in memory instead of the database
+type groupMessageService struct {
+	*services.MessageService
+	members   map[int][]int
+	delivered map[int]bool
+	lookups   int
+}
+
+func (s *groupMessageService) GetParticipants(message *models.MessageResponse) ([]int, error) {
+	if message.ConversationID != 0 {
+		return s.members[message.ConversationID], nil
+	}
+	return s.MessageService.GetParticipants(message)
+}
+
+func (s *groupMessageService) GetRecipients(senderID, receiverID, conversationID int) ([]int, error) {
+	s.lookups++
+
+	var recipients []int
+	for _, id := range s.members[conversationID] {
+		if id != senderID {
+			recipients = append(recipients, id)
+		}
+	}
+	return recipients, nil
+}
+
+func (s *groupMessageService) MarkDelivered(message *models.MessageResponse, recipientID int) (*models.ReceiptResponse, error) {
+	if s.delivered[message.ID] {
+		return nil, nil
+	}
+	s.delivered[message.ID] = true
+	return message.DeliveryReceipt(recipientID, time.Now()), nil
+}
+
+func TestDeliverEventMarksGroupMessageDeliveredForAllMembers(t *testing.T) {
+	db := newClosedDB(t)
+	hub := startTestHub(db, &groupMessageService{
+		MessageService: newTestMessageService(db),
+		members:        map[int][]int{7: {1, 2, 3}},
+		delivered:      make(map[int]bool),
+	})
+
+	sender := newTestClient(hub, 1)
+	members := []*Client{newTestClient(hub, 2), newTestClient(hub, 3)}
+
+	message := &models.MessageResponse{ID: 10, SenderID: 1, ConversationID: 7, Content: "hi", CreatedAt: time.Now()}
+	hub.PublishEvent(EventMessage, message)
+
+	for _, member := range members {
+		if got := receive(t, member); got.Type != EventMessage || got.Message.ID != message.ID {
+			t.Fatalf("member %d got %s, want message %d", member.UserID, got.Type, message.ID)
+		}
+	}
+
+	if got := receive(t, sender); got.Type != EventMessage {
+		t.Fatalf("sender got %s, want own message echoed", got.Type)
+	}
+
+	got := receive(t, sender)
+	if got.Type != EventReceipt || got.Receipt.Status != models.StatusDelivered {
+		t.Fatalf("sender got %s, want delivery receipt", got.Type)
+	}
+	if got.Receipt.UserID != 0 {
+		t.Errorf("group delivery receipt names user %d, want no user", got.Receipt.UserID)
+	}
+	if len(got.Receipt.MessageIDs) != 1 || got.Receipt.MessageIDs[0] != message.ID {
+		t.Errorf("receipt covers messages %v, want [%d]", got.Receipt.MessageIDs, message.ID)
+	}
+}
+
+func TestConsumeEventsSkipsEventsDeliveredLocally(t *testing.T) {
+	hub := newTestHub(t)
+	broker := services.NewMemoryBroker()
+	consumer := broker.Subscribe()
+	defer consumer.Close()
+	go hub.ConsumeEvents(consumer)
+
+	receiver := newTestClient(hub, 2)
+	message := &models.MessageResponse{ID: 10, SenderID: 1, ReceiverID: 2, Content: "hi", CreatedAt: time.Now()}
+
+	// Message sent through this instance comes back from Kafka after outbox relay publishes it
+	hub.PublishEvent(EventMessage, message)
+	broker.Publish(message.ToKafkaEvent(models.EventTypeMessageSent))
+
+	edited := *message
+	editedAt := message.CreatedAt.Add(time.Minute)
+	edited.Content = "hello"
+	edited.EditedAt = &editedAt
+	broker.Publish(edited.ToKafkaEvent(models.EventTypeMessageEdited))
+
+	if got := receive(t, receiver); got.Type != EventMessage || got.Message.ID != message.ID {
+		t.Fatalf("first frame = %s for message %v, want new message %d", got.Type, got.Message, message.ID)
+	}
+	if got := receive(t, receiver); got.Type != EventMessageEdited || got.Message.Content != "hello" {
+		t.Fatalf("second frame = %s, want edit of message %d; duplicate delivered?", got.Type, message.ID)
+	}
+}
+
+func TestConsumeEventsKeepsPartitionOrder(t *testing.T) {
+	hub := newTestHub(t)
+	broker := services.NewMemoryBroker()
+	consumer := broker.Subscribe()
+	defer consumer.Close()
+	go hub.ConsumeEvents(consumer)
+
+	publisher := services.NewMemoryPublisher()
+	receiver := newTestClient(hub, 2)
+
+	createdAt := time.Now()
+	for id := 1; id <= 5; id++ {
+		message := &models.MessageResponse{ID: id, SenderID: 1, ReceiverID: 2, CreatedAt: createdAt}
+		event := message.ToKafkaEvent(models.EventTypeMessageSent)
+		publisher.Publish(event)
+		broker.Publish(event)
+	}
+
+	keys := make(map[string]bool)
+	for _, event := range publisher.Events() {
+		keys[event.PartitionKey()] = true
+	}
+	if len(keys) != 1 {
+		t.Fatalf("events of one chat use %d partition keys, want 1", len(keys))
+	}
+
+	for id := 1; id <= 5; id++ {
+		if got := receive(t, receiver); got.Message == nil || got.Message.ID != id {
+			t.Fatalf("frame %d = %+v, want message %d", id, got, id)
+		}
+	}
+}
+
+func TestConsumeEventsSkipsUnknownTypes(t *testing.T) {
+	hub := newTestHub(t)
+	broker := services.NewMemoryBroker()
+	consumer := broker.Subscribe()
+	defer consumer.Close()
+	go hub.ConsumeEvents(consumer)
+
+	receiver := newTestClient(hub, 2)
+	message := &models.MessageResponse{ID: 10, SenderID: 1, ReceiverID: 2, CreatedAt: time.Now()}
+
+	broker.Publish(message.ToKafkaEvent("message.unknown"))
+	broker.Publish(message.ToKafkaEvent(models.EventTypeMessageSent))
+
+	if got := receive(t, receiver); got.Type != EventMessage {
+		t.Fatalf("frame = %s, want %s", got.Type, EventMessage)
+	}
+}
@@ -3,50 +3,163 @@ package websocket
 import (
 	"encoding/json"
 	"github.com/gorilla/websocket"
+	"github.com/squ1ky/talkify/internal/metrics"
 	"github.com/squ1ky/talkify/internal/models"
 	"log"
+	"sync"
 	"time"
 )
 
+// Conn is the part of a WebSocket connection used by Client, implemented by *websocket.Conn
+type Conn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	SetReadLimit(limit int64)
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	SetPongHandler(h func(appData string) error)
+	Close() error
+}
+
 // Client represents a single WebSocket connection from a user
+// Send is closed exactly once, by Close or when the client is disconnected as a slow consumer
 type Client struct {
-	Conn     *websocket.Conn
+	Conn     Conn
 	UserID   int
 	Username string
 	Send     chan []byte
 	Hub      *Hub
+
+	// TokenID is jti of the access token the connection was opened with
+	TokenID string
+
+	// Resume requests replay of missed messages right after registration
+	Resume *SyncRequest
+
+	mu        sync.Mutex
+	closed    bool
+	closeCode int
+	closeText string
+	sent      uint64
+	dropped   uint64
+	maxQueued int
+
+	// holding is set while replay is fetched; live traffic is kept in held until it's sent
+	holding bool
+	held    []OutgoingMessage
+}
+
+// ClientStats describes send queue of a connection
+type ClientStats struct {
+	UserID    int    `json:"user_id"`
+	Queued    int    `json:"queued"`
+	Capacity  int    `json:"capacity"`
+	MaxQueued int    `json:"max_queued"`
+	Sent      uint64 `json:"sent"`
+	Dropped   uint64 `json:"dropped"`
+	Closed    bool   `json:"closed"`
 }
 
+// Event types sent to client's browser
+const (
+	EventMessage        = "message"
+	EventMessageEdited  = "message_edited"
+	EventMessageDeleted = "message_deleted"
+	EventMessageUpdated = "message_updated"
+	EventReceipt        = "receipt"
+	EventReaction       = "reaction"
+	EventTypingStart    = "typing_start"
+	EventTypingStop     = "typing_stop"
+	EventSyncComplete   = "sync_complete"
+	EventPresence       = "presence"
+	EventAuthenticated  = "authenticated"
+	EventError          = "error"
+)
+
 // IncomingMessage represents message received from client's browser
 type IncomingMessage struct {
-	Type       string `json:"type"`
-	Content    string `json:"content"`
-	ReceiverID int    `json:"receiver_id"`
+	Type           string    `json:"type"`
+	Content        string    `json:"content"`
+	ReceiverID     int       `json:"receiver_id"`
+	ConversationID int       `json:"conversation_id"`
+	MessageID      int       `json:"message_id"`
+	Since          time.Time `json:"since"`
+	SinceID        int       `json:"since_id"`
+	State          string    `json:"state"`
+	Emoji          string    `json:"emoji"`
+	Token          string    `json:"token"`
+	Ticket         string    `json:"ticket"`
 }
 
 // OutgoingMessage represents message sent to client's browser
 type OutgoingMessage struct {
-	Type      string                  `json:"type"`
-	Message   *models.MessageResponse `json:"message,omitempty"`
-	Error     string                  `json:"error,omitempty"`
-	Timestamp time.Time               `json:"timestamp,omitempty"`
+	Type      string                   `json:"type"`
+	Message   *models.MessageResponse  `json:"message,omitempty"`
+	Receipt   *models.ReceiptResponse  `json:"receipt,omitempty"`
+	Reaction  *models.ReactionResponse `json:"reaction,omitempty"`
+	Typing    *TypingNotification      `json:"typing,omitempty"`
+	Sync      *SyncStatus              `json:"sync,omitempty"`
+	Presence  *models.Presence         `json:"presence,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+	Timestamp time.Time                `json:"timestamp,omitempty"`
 }
 
 // MessageRequest represents a message that needs to be processed by Hub
 type MessageRequest struct {
-	SenderID   int
-	ReceiverID int
-	Content    string
+	Client         *Client
+	SenderID       int
+	ReceiverID     int
+	ConversationID int
+	Content        string
+}
+
+// EditRequest represents a message edit that needs to be processed by Hub
+type EditRequest struct {
+	Client    *Client
+	UserID    int
+	MessageID int
+	Content   string
+}
+
+// ReadRequest represents a request to mark messages up to MessageID as read
+type ReadRequest struct {
+	Client    *Client
+	UserID    int
+	MessageID int
+}
+
+// DisconnectRequest represents request to close connections of a user
+type DisconnectRequest struct {
+	UserID  int    `json:"user_id"`
+	TokenID string `json:"token_id,omitempty"`
+}
+
+// MessageEvent represents a message-related event to be delivered to its participants
+// EventID is set for events coming from other instances and derived from Message otherwise
+// Remote events were already passed to the cluster by the instance they originated on
+type MessageEvent struct {
+	Type    string
+	Message *models.MessageResponse
+	EventID string
+	Remote  bool
 }
 
 // ReadPump reads messages from the WebSocket connection
 // Runs in its own goroutine
+// Connection is dropped if no pong arrives within PongWait
 func (c *Client) ReadPump() {
 	defer func() {
 		c.Hub.Unregister <- c
 		c.Conn.Close()
 	}()
 
+	cfg := c.Hub.config
+	c.Conn.SetReadLimit(cfg.MaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(cfg.PongWait))
+	c.Conn.SetPongHandler(func(string) error {
+		return c.Conn.SetReadDeadline(time.Now().Add(cfg.PongWait))
+	})
+
 	for {
 		_, messageBytes, err := c.Conn.ReadMessage()
 		if err != nil {
@@ -67,17 +180,22 @@ func (c *Client) ReadPump() {
 
 // WritePump sends messages to the WebSocket connection
 // Runs in its own goroutine
+// Sends pings every PingInterval to keep the connection alive and detect dead peers
 func (c *Client) WritePump() {
+	cfg := c.Hub.config
+	ticker := time.NewTicker(cfg.PingInterval)
 	defer func() {
+		ticker.Stop()
 		c.Conn.Close()
 	}()
 
 	for {
 		select {
 		case message, ok := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(cfg.WriteWait))
 			if !ok {
-				// Hub closed the chan
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				// Client was closed by Hub or as a slow consumer
+				c.Conn.WriteMessage(websocket.CloseMessage, c.closeMessage())
 				return
 			}
 
@@ -86,8 +204,14 @@ func (c *Client) WritePump() {
 				log.Printf("WebSocket write error for user %d: %v", c.UserID, err)
 				return
 			}
-		}
 
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(cfg.WriteWait))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("WebSocket ping error for user %d: %v", c.UserID, err)
+				return
+			}
+		}
 	}
 }
 
@@ -96,9 +220,51 @@ func (c *Client) handleIncomingMessage(msg IncomingMessage) {
 	switch msg.Type {
 	case "message":
 		c.Hub.HandleMessage <- &MessageRequest{
-			SenderID:   c.UserID,
-			ReceiverID: msg.ReceiverID,
-			Content:    msg.Content,
+			Client:         c,
+			SenderID:       c.UserID,
+			ReceiverID:     msg.ReceiverID,
+			ConversationID: msg.ConversationID,
+			Content:        msg.Content,
+		}
+	case "read":
+		c.Hub.HandleRead <- &ReadRequest{
+			Client:    c,
+			UserID:    c.UserID,
+			MessageID: msg.MessageID,
+		}
+	case EventTypingStart, EventTypingStop:
+		c.Hub.HandleTyping <- &TypingRequest{
+			UserID:         c.UserID,
+			ReceiverID:     msg.ReceiverID,
+			ConversationID: msg.ConversationID,
+			Typing:         msg.Type == EventTypingStart,
+		}
+	case "sync":
+		c.Hub.HandleSync <- &SyncRequest{
+			Client:  c,
+			Since:   msg.Since,
+			SinceID: msg.SinceID,
+		}
+	case "react", "unreact":
+		c.Hub.HandleReaction <- &ReactionRequest{
+			Client:    c,
+			UserID:    c.UserID,
+			MessageID: msg.MessageID,
+			Emoji:     msg.Emoji,
+			Remove:    msg.Type == "unreact",
+		}
+	case "presence":
+		c.Hub.HandlePresence <- &PresenceRequest{
+			Client: c,
+			UserID: c.UserID,
+			State:  msg.State,
+		}
+	case "edit":
+		c.Hub.HandleEdit <- &EditRequest{
+			Client:    c,
+			UserID:    c.UserID,
+			MessageID: msg.MessageID,
+			Content:   msg.Content,
 		}
 	default:
 		c.sendError("Unknown message type: " + msg.Type)
@@ -107,30 +273,91 @@ func (c *Client) handleIncomingMessage(msg IncomingMessage) {
 
 // sendError sends error message to client
 func (c *Client) sendError(errMsg string) {
-	outgoingMsg := OutgoingMessage{
-		Type:      "error",
+	c.send(OutgoingMessage{
+		Type:      EventError,
 		Error:     errMsg,
 		Timestamp: time.Now(),
-	}
-
-	data, _ := json.Marshal(outgoingMsg)
-	select {
-	case c.Send <- data:
-		// Message sent successfully
-	default:
-		// Channel is full, close connection
-		close(c.Send)
-	}
+	})
 }
 
 // SendMessage sends message to client
 func (c *Client) SendMessage(message *models.MessageResponse) {
-	outgoingMsg := OutgoingMessage{
-		Type:      "message",
+	c.sendEvent(EventMessage, message)
+}
+
+// sendEvent sends message-related event of given type to client
+func (c *Client) sendEvent(eventType string, message *models.MessageResponse) {
+	c.send(OutgoingMessage{
+		Type:      eventType,
 		Message:   message,
 		Timestamp: time.Now(),
+	})
+}
+
+// sendReceipt sends delivery or read receipt to client
+func (c *Client) sendReceipt(receipt *models.ReceiptResponse) {
+	c.send(OutgoingMessage{
+		Type:      EventReceipt,
+		Receipt:   receipt,
+		Timestamp: time.Now(),
+	})
+}
+
+// sendReaction sends reaction change on a message to client
+func (c *Client) sendReaction(reaction *models.ReactionResponse) {
+	c.send(OutgoingMessage{
+		Type:      EventReaction,
+		Reaction:  reaction,
+		Timestamp: time.Now(),
+	})
+}
+
+// sendTyping sends typing indicator to client
+func (c *Client) sendTyping(eventType string, notification *TypingNotification) {
+	c.send(OutgoingMessage{
+		Type:      eventType,
+		Typing:    notification,
+		Timestamp: time.Now(),
+	})
+}
+
+// sendPresence sends presence change of a contact to client
+func (c *Client) sendPresence(presence *models.Presence) {
+	c.send(OutgoingMessage{
+		Type:      EventPresence,
+		Presence:  presence,
+		Timestamp: time.Now(),
+	})
+}
+
+// send marshals outgoing message and queues it for WritePump
+// While replay is in progress, the message is held until replayed messages are queued
+func (c *Client) send(outgoingMsg OutgoingMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
 	}
 
+	if c.holding {
+		// One slot stays free for sync_complete
+		if len(c.held) < cap(c.Send)-1 {
+			c.held = append(c.held, outgoingMsg)
+			return
+		}
+
+		log.Printf("Disconnecting slow WebSocket client of user %d (%d messages held during sync)", c.UserID, len(c.held))
+		metrics.WSSlowConsumerDisconnects.Add(1)
+		c.closeLocked(websocket.CloseTryAgainLater, "send queue full")
+		return
+	}
+
+	c.enqueueLocked(outgoingMsg)
+}
+
+// enqueueLocked marshals outgoing message and queues it for WritePump, c.mu must be held
+func (c *Client) enqueueLocked(outgoingMsg OutgoingMessage) {
 	data, err := json.Marshal(outgoingMsg)
 	if err != nil {
 		log.Printf("Failed to marshal message for user %d: %v", c.UserID, err)
@@ -139,9 +366,142 @@ func (c *Client) SendMessage(message *models.MessageResponse) {
 
 	select {
 	case c.Send <- data:
-		// Message sent successfully
+		c.sent++
+		if queued := len(c.Send); queued > c.maxQueued {
+			c.maxQueued = queued
+		}
 	default:
-		// Channel is full, close connection
-		close(c.Send)
+		// Queue is full: typing indicators are expendable, anything else
+		// disconnects the client so it reconnects and resumes from its last message
+		c.dropped++
+		metrics.WSDroppedMessages.Add(1)
+		if isExpendable(outgoingMsg.Type) {
+			return
+		}
+
+		log.Printf("Disconnecting slow WebSocket client of user %d (%d messages queued)", c.UserID, len(c.Send))
+		metrics.WSSlowConsumerDisconnects.Add(1)
+		c.closeLocked(websocket.CloseTryAgainLater, "send queue full")
+	}
+}
+
+// hold starts keeping live traffic aside until release is called
+// Returns false if replay is already in progress
+func (c *Client) hold() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.holding {
+		return false
+	}
+	c.holding = true
+	return true
+}
+
+// release queues replayed frames, sync_complete marker if status is set, and then held live traffic
+// Held new messages already included in the replay are skipped
+// Replay is cut short when it doesn't fit Send next to held traffic, see cutReplay
+func (c *Client) release(replay []OutgoingMessage, status *SyncStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held := c.held
+	c.holding = false
+	c.held = nil
+
+	if status != nil {
+		room := max(0, cap(c.Send)-len(c.Send)-len(held)-1)
+		if len(replay) > room {
+			replay, status = cutReplay(replay, status, room)
+		}
+	}
+
+	replayedIDs := make(map[int]bool, len(replay))
+	for _, outgoingMsg := range replay {
+		if outgoingMsg.Type == EventMessage {
+			replayedIDs[outgoingMsg.Message.ID] = true
+		}
+		c.queueLocked(outgoingMsg)
+	}
+
+	if status != nil {
+		c.queueLocked(OutgoingMessage{
+			Type:      EventSyncComplete,
+			Sync:      status,
+			Timestamp: time.Now(),
+		})
+	}
+
+	for _, outgoingMsg := range held {
+		if outgoingMsg.Type == EventMessage && replayedIDs[outgoingMsg.Message.ID] {
+			continue
+		}
+		c.queueLocked(outgoingMsg)
+	}
+}
+
+// queueLocked queues outgoing message unless client was closed meanwhile, c.mu must be held
+func (c *Client) queueLocked(outgoingMsg OutgoingMessage) {
+	if !c.closed {
+		c.enqueueLocked(outgoingMsg)
+	}
+}
+
+// isExpendable reports whether event may be dropped without disconnecting the client
+func isExpendable(eventType string) bool {
+	return eventType == EventTypingStart || eventType == EventTypingStop
+}
+
+// Close stops queueing messages and lets WritePump close the connection
+// Safe to call several times and from any goroutine
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closeLocked(websocket.CloseNormalClosure, "")
+}
+
+// closeLocked discards queued messages and closes Send, c.mu must be held
+func (c *Client) closeLocked(code int, text string) {
+	if c.closed {
+		return
+	}
+
+	c.closed = true
+	c.closeCode = code
+	c.closeText = text
+	c.held = nil
+
+	for {
+		select {
+		case <-c.Send:
+		default:
+			close(c.Send)
+			return
+		}
+	}
+}
+
+// closeMessage returns payload of close frame WritePump sends after Send is closed
+func (c *Client) closeMessage() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return websocket.FormatCloseMessage(c.closeCode, c.closeText)
+}
+
+// Stats returns snapshot of client's send queue
+func (c *Client) Stats() ClientStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ClientStats{
+		UserID:    c.UserID,
+		Queued:    len(c.Send),
+		Capacity:  cap(c.Send),
+		MaxQueued: c.maxQueued,
+		Sent:      c.sent,
+		Dropped:   c.dropped,
+		Closed:    c.closed,
 	}
 }
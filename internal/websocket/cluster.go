@@ -0,0 +1,201 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/squ1ky/talkify/internal/models"
+	"github.com/squ1ky/talkify/internal/services"
+	"log"
+)
+
+// Cluster notification kinds
+const (
+	clusterKindMessage    = "message"
+	clusterKindReceipt    = "receipt"
+	clusterKindTyping     = "typing"
+	clusterKindDisconnect = "disconnect"
+	clusterKindPresence   = "presence"
+	clusterKindReaction   = "reaction"
+)
+
+const (
+	// maxReceiptIDs caps message IDs per receipt notification to stay within NOTIFY payload limit
+	maxReceiptIDs = 500
+
+	// maxTypingRecipients caps recipients per typing notification to stay within NOTIFY payload limit
+	maxTypingRecipients = 500
+)
+
+// clusterMessageEvent is payload of message notification
+// Content is not sent, other nodes load the message from the database
+type clusterMessageEvent struct {
+	EventID   string `json:"event_id"`
+	Type      string `json:"type"`
+	MessageID int    `json:"message_id"`
+}
+
+// clusterTypingEvent is payload of typing notification
+// Recipients are resolved by the typing user's instance, so other nodes don't query the database
+type clusterTypingEvent struct {
+	Type       string             `json:"type"`
+	Typing     TypingNotification `json:"typing"`
+	Recipients []int              `json:"recipients"`
+}
+
+// ListenCluster delivers notifications from other instances to local clients
+// Blocks until cluster bus is closed, so it should be called in a goroutine
+func (h *Hub) ListenCluster() {
+	if h.cluster == nil {
+		return
+	}
+
+	err := h.cluster.Listen(func(notification services.ClusterNotification) {
+		h.ClusterNotifications <- &notification
+	})
+
+	if err != nil && !errors.Is(err, services.ErrClusterClosed) {
+		log.Printf("Cluster listener stopped: %v", err)
+	}
+}
+
+// processClusterNotification delivers notification of another instance to local clients
+// Nothing is published back to the cluster, the origin node already did that
+func (h *Hub) processClusterNotification(notification *services.ClusterNotification) {
+	switch notification.Kind {
+	case clusterKindMessage:
+		var event clusterMessageEvent
+		if err := json.Unmarshal(notification.Payload, &event); err != nil {
+			log.Printf("Skipping malformed cluster message event: %v", err)
+			return
+		}
+
+		message, err := h.messageService.GetMessage(event.MessageID)
+		if err != nil {
+			log.Printf("Failed to load message %d from cluster event: %v", event.MessageID, err)
+			return
+		}
+
+		h.deliverEvent(&MessageEvent{
+			Type:    event.Type,
+			Message: message,
+			EventID: event.EventID,
+			Remote:  true,
+		})
+
+	case clusterKindReceipt:
+		var receipt models.ReceiptResponse
+		if err := json.Unmarshal(notification.Payload, &receipt); err != nil {
+			log.Printf("Skipping malformed cluster receipt: %v", err)
+			return
+		}
+
+		h.sendReceipt(&receipt)
+
+	case clusterKindTyping:
+		var event clusterTypingEvent
+		if err := json.Unmarshal(notification.Payload, &event); err != nil {
+			log.Printf("Skipping malformed cluster typing event: %v", err)
+			return
+		}
+
+		h.sendTyping(event.Recipients, event.Type, &event.Typing)
+
+	case clusterKindDisconnect:
+		var req DisconnectRequest
+		if err := json.Unmarshal(notification.Payload, &req); err != nil {
+			log.Printf("Skipping malformed cluster disconnect: %v", err)
+			return
+		}
+
+		h.disconnect(&req)
+
+	case clusterKindPresence:
+		var presence models.Presence
+		if err := json.Unmarshal(notification.Payload, &presence); err != nil {
+			log.Printf("Skipping malformed cluster presence: %v", err)
+			return
+		}
+
+		h.deliverPresence(&presence, true)
+
+	case clusterKindReaction:
+		var reaction models.ReactionResponse
+		if err := json.Unmarshal(notification.Payload, &reaction); err != nil {
+			log.Printf("Skipping malformed cluster reaction: %v", err)
+			return
+		}
+
+		h.deliverReaction(&reaction, true)
+
+	default:
+		log.Printf("Skipping cluster notification of unknown kind %q", notification.Kind)
+	}
+}
+
+// publishMessageEvent tells other instances about message event delivered by this Hub
+func (h *Hub) publishMessageEvent(eventID string, event *MessageEvent) {
+	h.publishCluster(clusterKindMessage, clusterMessageEvent{
+		EventID:   eventID,
+		Type:      event.Type,
+		MessageID: event.Message.ID,
+	})
+}
+
+// publishReceipt sends receipt to other instances, split into chunks that fit NOTIFY payload
+func (h *Hub) publishReceipt(receipt *models.ReceiptResponse) {
+	if h.cluster == nil {
+		return
+	}
+
+	for start := 0; start < len(receipt.MessageIDs); start += maxReceiptIDs {
+		end := min(start+maxReceiptIDs, len(receipt.MessageIDs))
+
+		chunk := *receipt
+		chunk.MessageIDs = receipt.MessageIDs[start:end]
+		h.publishCluster(clusterKindReceipt, chunk)
+	}
+}
+
+// publishTyping sends typing state change to other instances, split into chunks of recipients that fit NOTIFY payload
+func (h *Hub) publishTyping(eventType string, notification *TypingNotification, recipients []int) {
+	if h.cluster == nil {
+		return
+	}
+
+	for start := 0; start < len(recipients); start += maxTypingRecipients {
+		end := min(start+maxTypingRecipients, len(recipients))
+
+		h.publishCluster(clusterKindTyping, clusterTypingEvent{
+			Type:       eventType,
+			Typing:     *notification,
+			Recipients: recipients[start:end],
+		})
+	}
+}
+
+// publishDisconnect asks other instances to close user's connections
+func (h *Hub) publishDisconnect(req *DisconnectRequest) {
+	h.publishCluster(clusterKindDisconnect, req)
+}
+
+// publishCluster sends notification to other instances if clustering is enabled
+func (h *Hub) publishCluster(kind string, payload interface{}) {
+	if h.cluster == nil {
+		return
+	}
+
+	if err := h.cluster.Publish(kind, payload); err != nil {
+		log.Printf("Failed to publish %s cluster notification: %v", kind, err)
+	}
+}
+
+// setOnline records user's presence on this node in the cluster
+func (h *Hub) setOnline(userID int, online bool) {
+	if h.cluster == nil {
+		return
+	}
+
+	if err := h.cluster.SetOnline(userID, online); err != nil {
+		log.Printf("Failed to update cluster presence of user %d: %v", userID, err)
+	}
+}
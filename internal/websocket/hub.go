@@ -1,28 +1,86 @@
 package websocket
 
 import (
+	"github.com/squ1ky/talkify/internal/config"
 	"github.com/squ1ky/talkify/internal/models"
 	"github.com/squ1ky/talkify/internal/services"
 	"log"
+	"time"
 )
 
+// MessageService is the part of services.MessageService used by Hub
+type MessageService interface {
+	SendMessage(senderID int, req models.MessageCreateRequest) (*models.MessageResponse, error)
+	EditMessage(userID, messageID int, req models.MessageUpdateRequest) (*models.MessageResponse, error)
+	GetMessage(messageID int) (*models.MessageResponse, error)
+	GetMessagesSince(userID int, since time.Time, sinceID, limit int) (*models.MessageSyncResponse, error)
+	GetParticipants(message *models.MessageResponse) ([]int, error)
+	GetRecipients(senderID, receiverID, conversationID int) ([]int, error)
+	MarkDelivered(message *models.MessageResponse, recipientID int) (*models.ReceiptResponse, error)
+	MarkRead(userID, messageID int) ([]models.ReceiptResponse, error)
+	React(userID, messageID int, emoji string) (*models.ReactionResponse, error)
+	Unreact(userID, messageID int, emoji string) (*models.ReactionResponse, error)
+}
+
 // Hub manages all WebSocket connections and message routing
+// A user may have several simultaneous connections (tabs, devices)
+// With a cluster bus, events are also exchanged with hubs of other instances
+// Hub state is owned by the Run goroutine; other goroutines use channels or exported methods
 type Hub struct {
-	clients        map[int]*Client
-	Register       chan *Client
-	Unregister     chan *Client
-	HandleMessage  chan *MessageRequest
-	messageService *services.MessageService
+	clients              map[int]map[*Client]bool
+	Register             chan *Client
+	Unregister           chan *Client
+	HandleMessage        chan *MessageRequest
+	HandleEdit           chan *EditRequest
+	HandleRead           chan *ReadRequest
+	HandleTyping         chan *TypingRequest
+	HandleSync           chan *SyncRequest
+	HandlePresence       chan *PresenceRequest
+	HandleReaction       chan *ReactionRequest
+	Events               chan *MessageEvent
+	Receipts             chan *models.ReceiptResponse
+	Reactions            chan *models.ReactionResponse
+	Disconnect           chan *DisconnectRequest
+	PresenceChanges      chan *models.Presence
+	ClusterNotifications chan *services.ClusterNotification
+	queries              chan func()
+	replays              chan *replayResult
+	typing               map[typingKey]*typingSession
+	delivered            map[string]time.Time
+	config               config.WebSocketConfig
+	cluster              services.ClusterBus
+	messageService       MessageService
+	presence             *services.PresenceService
 }
 
 // NewHub creates a new Hub instance
-func NewHub(messageService *services.MessageService) *Hub {
+// cluster may be nil when the instance runs alone
+func NewHub(messageService MessageService, presence *services.PresenceService, cluster services.ClusterBus, cfg config.WebSocketConfig) *Hub {
 	return &Hub{
-		clients:        make(map[int]*Client),
-		Register:       make(chan *Client),
-		Unregister:     make(chan *Client),
-		HandleMessage:  make(chan *MessageRequest),
-		messageService: messageService,
+		clients:              make(map[int]map[*Client]bool),
+		Register:             make(chan *Client),
+		Unregister:           make(chan *Client),
+		HandleMessage:        make(chan *MessageRequest),
+		HandleEdit:           make(chan *EditRequest),
+		HandleRead:           make(chan *ReadRequest),
+		HandleTyping:         make(chan *TypingRequest),
+		HandleSync:           make(chan *SyncRequest),
+		HandlePresence:       make(chan *PresenceRequest),
+		HandleReaction:       make(chan *ReactionRequest),
+		Events:               make(chan *MessageEvent),
+		Receipts:             make(chan *models.ReceiptResponse),
+		Reactions:            make(chan *models.ReactionResponse),
+		Disconnect:           make(chan *DisconnectRequest),
+		PresenceChanges:      make(chan *models.Presence),
+		ClusterNotifications: make(chan *services.ClusterNotification),
+		queries:              make(chan func()),
+		replays:              make(chan *replayResult),
+		typing:               make(map[typingKey]*typingSession),
+		delivered:            make(map[string]time.Time),
+		config:               cfg,
+		cluster:              cluster,
+		messageService:       messageService,
+		presence:             presence,
 	}
 }
 
@@ -31,69 +89,359 @@ func NewHub(messageService *services.MessageService) *Hub {
 func (h *Hub) Run() {
 	log.Println("Websocket Hub started")
 
+	typingTicker := time.NewTicker(typingSweepInterval)
+	defer typingTicker.Stop()
+
+	deliveredTicker := time.NewTicker(deliveredSweepInterval)
+	defer deliveredTicker.Stop()
+
 	for {
 		select {
 		case client := <-h.Register: // Client connected
-			h.clients[client.UserID] = client
-			log.Printf("User %d (%s) connected to WebSocket", client.UserID, client.Username)
+			h.addClient(client)
+			if client.Resume != nil {
+				h.replay(client.Resume)
+			}
 
 		case client := <-h.Unregister: // Client disconnected
-			if _, ok := h.clients[client.UserID]; ok {
-				delete(h.clients, client.UserID)
-				close(client.Send)
-				log.Printf("User %d (%s) disconnected from WebSocket", client.UserID, client.Username)
-			}
+			h.removeClient(client)
 
 		case messageReq := <-h.HandleMessage: // Handle incoming message from client
 			h.processMessage(messageReq)
+
+		case editReq := <-h.HandleEdit: // Handle message edit from client
+			h.processEdit(editReq)
+
+		case readReq := <-h.HandleRead: // Handle read confirmation from client
+			h.processRead(readReq)
+
+		case typingReq := <-h.HandleTyping: // Relay typing indicator, no database writes
+			h.processTyping(typingReq)
+
+		case syncReq := <-h.HandleSync: // Replay missed messages on client's request
+			h.replay(syncReq)
+
+		case result := <-h.replays: // Send replay fetched in the background
+			h.finishReplay(result)
+
+		case reactionReq := <-h.HandleReaction: // Add or remove emoji reaction
+			h.processReaction(reactionReq)
+
+		case presenceReq := <-h.HandlePresence: // Switch user between online and away
+			h.processPresence(presenceReq)
+
+		case now := <-typingTicker.C: // Expire typing state of silent clients
+			h.expireTyping(now)
+
+		case now := <-deliveredTicker.C: // Forget IDs of long delivered events
+			h.expireDelivered(now)
+
+		case event := <-h.Events: // Deliver event published from outside or consumed from Kafka
+			h.deliverEvent(event)
+
+		case receipt := <-h.Receipts: // Deliver receipt published from outside
+			h.deliverReceipt(receipt)
+
+		case reaction := <-h.Reactions: // Deliver reaction change published from outside
+			h.deliverReaction(reaction, false)
+
+		case presence := <-h.PresenceChanges: // Announce presence changed from outside
+			h.deliverPresence(presence, false)
+
+		case req := <-h.Disconnect: // Close connections of a user who logged out
+			h.disconnect(req)
+			h.publishDisconnect(req)
+
+		case notification := <-h.ClusterNotifications: // Deliver event from another instance
+			h.processClusterNotification(notification)
+
+		case query := <-h.queries: // Access hub state on behalf of another goroutine
+			query()
 		}
 	}
 }
 
-// processMessage handles message creation and delivery
+// query runs fn in the Run goroutine and waits for it to return
+// Must not be called from the Run goroutine itself
+func (h *Hub) query(fn func()) {
+	done := make(chan struct{})
+	h.queries <- func() {
+		fn()
+		close(done)
+	}
+	<-done
+}
+
+// addClient registers a new connection of a user
+func (h *Hub) addClient(client *Client) {
+	connections, ok := h.clients[client.UserID]
+	if !ok {
+		connections = make(map[*Client]bool)
+		h.clients[client.UserID] = connections
+		h.setOnline(client.UserID, true)
+	}
+	connections[client] = true
+
+	if !ok {
+		h.userOnline(client.UserID)
+	}
+
+	log.Printf("User %d (%s) connected to WebSocket (%d connections)", client.UserID, client.Username, len(connections))
+}
+
+// removeClient unregisters a connection; the user goes offline when the last one is gone
+func (h *Hub) removeClient(client *Client) {
+	connections, ok := h.clients[client.UserID]
+	if !ok || !connections[client] {
+		return
+	}
+
+	delete(connections, client)
+	client.Close()
+
+	if len(connections) > 0 {
+		log.Printf("User %d (%s) closed a WebSocket connection (%d left)", client.UserID, client.Username, len(connections))
+		return
+	}
+
+	delete(h.clients, client.UserID)
+	h.setOnline(client.UserID, false)
+	h.stopUserTyping(client.UserID)
+	h.userOffline(client.UserID)
+	log.Printf("User %d (%s) disconnected from WebSocket", client.UserID, client.Username)
+}
+
+// disconnect closes user's connections opened with the given token, or all of them if TokenID is empty
+func (h *Hub) disconnect(req *DisconnectRequest) {
+	for client := range h.clients[req.UserID] {
+		if req.TokenID == "" || client.TokenID == req.TokenID {
+			h.removeClient(client)
+		}
+	}
+}
+
+// forEachClient calls fn for every connection of a user
+// Returns false if user has no connections
+func (h *Hub) forEachClient(userID int, fn func(*Client)) bool {
+	connections, ok := h.clients[userID]
+	if !ok {
+		return false
+	}
+
+	for client := range connections {
+		fn(client)
+	}
+	return true
+}
+
+// processMessage handles message creation and delivery to every online participant
 func (h *Hub) processMessage(req *MessageRequest) {
 	createReq := models.MessageCreateRequest{
-		ReceiverID: req.ReceiverID,
-		Content:    req.Content,
+		ReceiverID:     req.ReceiverID,
+		ConversationID: req.ConversationID,
+		Content:        req.Content,
 	}
 
 	messageResp, err := h.messageService.SendMessage(req.SenderID, createReq)
 	if err != nil {
-		if senderClient, ok := h.clients[req.SenderID]; ok {
-			senderClient.sendError("Failed to Send message: " + err.Error())
-		}
+		req.Client.sendError("Failed to Send message: " + err.Error())
 		log.Printf("Failed to save message from user %d: %v", req.SenderID, err)
 		return
 	}
 
-	if receiverClient, isOnline := h.clients[req.ReceiverID]; isOnline {
-		receiverClient.SendMessage(messageResp)
+	h.stopTyping(typingKey{
+		userID:         req.SenderID,
+		receiverID:     req.ReceiverID,
+		conversationID: req.ConversationID,
+	})
+
+	h.deliverEvent(&MessageEvent{Type: EventMessage, Message: messageResp})
+}
+
+// processEdit handles message edit and notifies participants
+func (h *Hub) processEdit(req *EditRequest) {
+	updateReq := models.MessageUpdateRequest{
+		Content: req.Content,
 	}
 
-	if senderClient, exists := h.clients[req.SenderID]; exists {
-		senderClient.SendMessage(messageResp)
+	messageResp, err := h.messageService.EditMessage(req.UserID, req.MessageID, updateReq)
+	if err != nil {
+		req.Client.sendError("Failed to edit message: " + err.Error())
+		log.Printf("Failed to edit message %d by user %d: %v", req.MessageID, req.UserID, err)
+		return
+	}
+
+	h.deliverEvent(&MessageEvent{Type: EventMessageEdited, Message: messageResp})
+}
+
+// processRead marks messages as read and sends receipts to their senders
+func (h *Hub) processRead(req *ReadRequest) {
+	receipts, err := h.messageService.MarkRead(req.UserID, req.MessageID)
+	if err != nil {
+		req.Client.sendError("Failed to mark messages read: " + err.Error())
+		log.Printf("Failed to mark messages read by user %d: %v", req.UserID, err)
+		return
+	}
+
+	for i := range receipts {
+		h.deliverReceipt(&receipts[i])
+	}
+}
+
+// markDelivered records message delivery and notifies its sender
+func (h *Hub) markDelivered(message *models.MessageResponse, recipientID int) {
+	receipt, err := h.messageService.MarkDelivered(message, recipientID)
+	if err != nil {
+		log.Printf("Failed to mark message %d delivered: %v", message.ID, err)
+		return
+	}
+
+	if receipt != nil {
+		h.deliverReceipt(receipt)
+	}
+}
+
+// deliverReceipt sends receipt to sender's connections on this and other instances
+func (h *Hub) deliverReceipt(receipt *models.ReceiptResponse) {
+	h.sendReceipt(receipt)
+	h.publishReceipt(receipt)
+}
+
+// sendReceipt sends receipt to message sender if they're online on this instance
+func (h *Hub) sendReceipt(receipt *models.ReceiptResponse) {
+	h.forEachClient(receipt.SenderID, func(client *Client) {
+		client.sendReceipt(receipt)
+	})
+}
+
+// notifyParticipants sends message event to every online participant of the message
+// Returns IDs of online participants other than the sender
+func (h *Hub) notifyParticipants(eventType string, message *models.MessageResponse) []int {
+	participants, err := h.messageService.GetParticipants(message)
+	if err != nil {
+		log.Printf("Failed to get participants of message %d: %v", message.ID, err)
+		return nil
+	}
+
+	var recipients []int
+	for _, userID := range participants {
+		isOnline := h.forEachClient(userID, func(client *Client) {
+			client.sendEvent(eventType, message)
+		})
+		if isOnline && userID != message.SenderID {
+			recipients = append(recipients, userID)
+		}
 	}
+
+	return recipients
 }
 
-// BroadcastMessage sends a message to specific user if they're online
-// This can be called from outside (e.g., REST API, Kafka consumer)
+// PublishReceipts queues receipts for delivery to message senders
+// This can be called from outside (e.g., REST API)
+func (h *Hub) PublishReceipts(receipts []models.ReceiptResponse) {
+	for i := range receipts {
+		h.Receipts <- &receipts[i]
+	}
+}
+
+// PublishEvent queues message event for delivery to all online participants
+// This can be called from outside (e.g., REST API)
+func (h *Hub) PublishEvent(eventType string, message *models.MessageResponse) {
+	h.Events <- &MessageEvent{
+		Type:    eventType,
+		Message: message,
+	}
+}
+
+// DisconnectUser closes user's connections on every instance after logout
+// If tokenID is empty, all connections of the user are closed
+func (h *Hub) DisconnectUser(userID int, tokenID string) {
+	h.Disconnect <- &DisconnectRequest{
+		UserID:  userID,
+		TokenID: tokenID,
+	}
+}
+
+// BroadcastMessage sends a message to every connection of specific user if they're online
+// This can be called from outside (e.g., REST API)
 func (h *Hub) BroadcastMessage(userID int, message *models.MessageResponse) {
-	if client, exists := h.clients[userID]; exists {
-		client.SendMessage(message)
+	h.query(func() {
+		h.forEachClient(userID, func(client *Client) {
+			client.SendMessage(message)
+		})
+	})
+}
+
+// SendQueueStats summarizes send queues of all connections on this instance
+// Nothing identifies users, so it is safe to publish as a metric
+type SendQueueStats struct {
+	Connections int    `json:"connections"`
+	Queued      int    `json:"queued"`
+	MaxQueued   int    `json:"max_queued"`
+	Sent        uint64 `json:"sent"`
+	Dropped     uint64 `json:"dropped"`
+}
+
+// SendQueueStats returns summary of send queues of all connections on this instance
+func (h *Hub) SendQueueStats() SendQueueStats {
+	var summary SendQueueStats
+	for _, stats := range h.ClientStats() {
+		summary.Connections++
+		summary.Queued += stats.Queued
+		summary.MaxQueued = max(summary.MaxQueued, stats.MaxQueued)
+		summary.Sent += stats.Sent
+		summary.Dropped += stats.Dropped
 	}
+	return summary
+}
+
+// ClientStats returns send queue stats of every connection on this instance
+func (h *Hub) ClientStats() []ClientStats {
+	var stats []ClientStats
+	h.query(func() {
+		for _, connections := range h.clients {
+			for client := range connections {
+				stats = append(stats, client.Stats())
+			}
+		}
+	})
+	return stats
 }
 
 // GetOnlineUsers returns slice of currently connected user IDs
+// With a cluster bus, users connected to any instance are included
 func (h *Hub) GetOnlineUsers() []int {
-	userIDs := make([]int, 0, len(h.clients))
-	for userID := range h.clients {
-		userIDs = append(userIDs, userID)
+	if h.cluster != nil {
+		userIDs, err := h.cluster.OnlineUsers()
+		if err == nil {
+			return userIDs
+		}
+		log.Printf("Failed to get cluster presence, using local connections: %v", err)
 	}
+
+	var userIDs []int
+	h.query(func() {
+		userIDs = make([]int, 0, len(h.clients))
+		for userID := range h.clients {
+			userIDs = append(userIDs, userID)
+		}
+	})
 	return userIDs
 }
 
-// IsUserOnline checks if specific user is connected
+// IsUserOnline checks if specific user is connected to any instance
 func (h *Hub) IsUserOnline(userID int) bool {
-	_, ok := h.clients[userID]
-	return ok
+	if h.cluster != nil {
+		online, err := h.cluster.IsOnline(userID)
+		if err == nil {
+			return online
+		}
+		log.Printf("Failed to get cluster presence of user %d, using local connections: %v", userID, err)
+	}
+
+	var online bool
+	h.query(func() {
+		_, online = h.clients[userID]
+	})
+	return online
 }
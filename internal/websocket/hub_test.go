@@ -0,0 +1,370 @@
+package websocket
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/squ1ky/talkify/internal/config"
+	"github.com/squ1ky/talkify/internal/database"
+	"github.com/squ1ky/talkify/internal/models"
+	"github.com/squ1ky/talkify/internal/services"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newClosedDB returns database that is already closed,
+// so every query fails fast and the Hub carries on as it does on database errors
+func newClosedDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	sqlDB, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB.Close()
+	return &database.DB{DB: sqlDB}
+}
+
+// newTestMessageService creates MessageService backed by db
+func newTestMessageService(db *database.DB) *services.MessageService {
+	return services.NewMessageService(
+		database.NewMessageRepository(db),
+		database.NewUserRepository(db),
+		database.NewConversationRepository(db),
+		database.NewReactionRepository(db),
+	)
+}
+
+// newTestHub starts Hub whose services are backed by a closed database
+func newTestHub(t *testing.T) *Hub {
+	t.Helper()
+
+	db := newClosedDB(t)
+	return startTestHub(db, newTestMessageService(db))
+}
+
+// startTestHub starts Hub using messageService, with presence backed by db
+func startTestHub(db *database.DB, messageService MessageService) *Hub {
+	presenceService := services.NewPresenceService(database.NewPresenceRepository(db))
+
+	hub := NewHub(messageService, presenceService, nil, config.WebSocketConfig{
+		PingInterval:   time.Minute,
+		PongWait:       2 * time.Minute,
+		WriteWait:      100 * time.Millisecond,
+		MaxMessageSize: 4096,
+		SendBufferSize: 256,
+	})
+	go hub.Run()
+	return hub
+}
+
+// newTestClient registers client of a user without running its pumps
+func newTestClient(hub *Hub, userID int) *Client {
+	client := &Client{
+		UserID: userID,
+		Send:   make(chan []byte, hub.config.SendBufferSize),
+		Hub:    hub,
+	}
+	hub.Register <- client
+	return client
+}
+
+// receive waits for the next frame queued for client
+func receive(t *testing.T, client *Client) OutgoingMessage {
+	t.Helper()
+
+	select {
+	case data, ok := <-client.Send:
+		if !ok {
+			t.Fatal("client was closed")
+		}
+		var msg OutgoingMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("failed to unmarshal frame: %v", err)
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no frame received")
+	}
+	return OutgoingMessage{}
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+var (
+	errFakeConnClosed   = errors.New("fake connection closed")
+	errFakeWriteTimeout = errors.New("fake connection write timeout")
+)
+
+// fakeConn is an in-memory Conn that records text frames written by WritePump
+// Reads block until Close, like an idle browser; writes time out while paused, like a stalled network
+type fakeConn struct {
+	mu            sync.Mutex
+	written       [][]byte
+	paused        bool
+	writeDeadline time.Time
+	closed        chan struct{}
+	once          sync.Once
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{closed: make(chan struct{})}
+}
+
+// pause makes writes block until their deadline
+func (c *fakeConn) pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.paused = true
+}
+
+func (c *fakeConn) ReadMessage() (int, []byte, error) {
+	<-c.closed
+	return 0, nil, errFakeConnClosed
+}
+
+func (c *fakeConn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	paused, deadline := c.paused, c.writeDeadline
+	c.mu.Unlock()
+
+	if paused {
+		select {
+		case <-time.After(time.Until(deadline)):
+			return errFakeWriteTimeout
+		case <-c.closed:
+			return errFakeConnClosed
+		}
+	}
+
+	select {
+	case <-c.closed:
+		return errFakeConnClosed
+	default:
+	}
+
+	if messageType == websocket.TextMessage {
+		c.mu.Lock()
+		c.written = append(c.written, data)
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+func (c *fakeConn) frames() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.written)
+}
+
+func (c *fakeConn) SetReadLimit(int64) {}
+
+func (c *fakeConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (c *fakeConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeDeadline = t
+	return nil
+}
+
+func (c *fakeConn) SetPongHandler(func(string) error) {}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// connect registers client of a user over fake connection and starts its pumps
+func connect(hub *Hub, userID int) (*Client, *fakeConn) {
+	conn := newFakeConn()
+	client := &Client{
+		Conn:   conn,
+		UserID: userID,
+		Send:   make(chan []byte, hub.config.SendBufferSize),
+		Hub:    hub,
+	}
+
+	hub.Register <- client
+	go client.WritePump()
+	go client.ReadPump()
+	return client, conn
+}
+
+func TestHubConcurrentAccess(t *testing.T) {
+	hub := newTestHub(t)
+
+	const users = 20
+	const connectionsPerUser = 2
+
+	var mu sync.Mutex
+	conns := make(map[int][]*fakeConn)
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func(i int) {
+			defer readers.Done()
+			message := &models.MessageResponse{ID: i, SenderID: 1, ReceiverID: 2}
+			for userID := 1; ; userID = userID%users + 1 {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				hub.GetOnlineUsers()
+				hub.IsUserOnline(userID)
+				// Broadcast to offline users, flooding connected ones would disconnect them as slow consumers
+				hub.BroadcastMessage(users+userID, message)
+				hub.ClientStats()
+				hub.SendQueueStats()
+			}
+		}(i)
+	}
+
+	var writers sync.WaitGroup
+	for userID := 1; userID <= users; userID++ {
+		for i := 0; i < connectionsPerUser; i++ {
+			writers.Add(1)
+			go func(userID int) {
+				defer writers.Done()
+				_, conn := connect(hub, userID)
+
+				mu.Lock()
+				conns[userID] = append(conns[userID], conn)
+				mu.Unlock()
+			}(userID)
+		}
+	}
+	writers.Wait()
+
+	waitFor(t, "all users online", func() bool {
+		return len(hub.GetOnlineUsers()) == users
+	})
+
+	// Close every connection of odd users and one connection of even users
+	for userID, userConns := range conns {
+		for i, conn := range userConns {
+			if userID%2 == 1 || i == 0 {
+				writers.Add(1)
+				go func(conn *fakeConn) {
+					defer writers.Done()
+					conn.Close()
+				}(conn)
+			}
+		}
+	}
+	writers.Wait()
+
+	waitFor(t, "odd users offline", func() bool {
+		return len(hub.GetOnlineUsers()) == users/2
+	})
+
+	close(stop)
+	readers.Wait()
+
+	online := hub.GetOnlineUsers()
+	sort.Ints(online)
+	for i, userID := range online {
+		if want := 2 * (i + 1); userID != want {
+			t.Fatalf("GetOnlineUsers() = %v, want even users only", online)
+		}
+	}
+
+	if stats := hub.SendQueueStats(); stats.Connections != users/2 {
+		t.Errorf("SendQueueStats().Connections = %d, want %d", stats.Connections, users/2)
+	}
+	for _, userID := range []int{1, 2} {
+		if got, want := hub.IsUserOnline(userID), userID%2 == 0; got != want {
+			t.Errorf("IsUserOnline(%d) = %v, want %v", userID, got, want)
+		}
+	}
+}
+
+func TestBroadcastMessageReachesEveryConnection(t *testing.T) {
+	hub := newTestHub(t)
+
+	var conns []*fakeConn
+	for i := 0; i < 3; i++ {
+		_, conn := connect(hub, 1)
+		conns = append(conns, conn)
+	}
+	_, other := connect(hub, 2)
+
+	hub.BroadcastMessage(1, &models.MessageResponse{ID: 10, SenderID: 2, ReceiverID: 1})
+
+	for i, conn := range conns {
+		waitFor(t, "broadcast frame", func() bool { return conn.frames() == 1 })
+		if n := conn.frames(); n != 1 {
+			t.Errorf("connection %d received %d frames, want 1", i, n)
+		}
+	}
+	if n := other.frames(); n != 0 {
+		t.Errorf("connection of another user received %d frames, want 0", n)
+	}
+}
+
+func TestSlowConsumerIsDisconnected(t *testing.T) {
+	hub := newTestHub(t)
+
+	client, conn := connect(hub, 1)
+	conn.pause()
+
+	message := &models.MessageResponse{ID: 10, SenderID: 2, ReceiverID: 1}
+	for i := 0; i <= hub.config.SendBufferSize+1; i++ {
+		hub.BroadcastMessage(1, message)
+	}
+
+	stats := client.Stats()
+	if !stats.Closed || stats.Dropped == 0 {
+		t.Fatalf("Stats() = %+v, want closed client with dropped messages", stats)
+	}
+
+	// WritePump closes the connection, ReadPump then unregisters the client
+	waitFor(t, "slow consumer unregistered", func() bool {
+		return !hub.IsUserOnline(1)
+	})
+}
+
+func TestExpendableEventsDoNotDisconnect(t *testing.T) {
+	hub := newTestHub(t)
+	client := newTestClient(hub, 1)
+
+	for i := 0; i < hub.config.SendBufferSize+10; i++ {
+		client.sendTyping(EventTypingStart, &TypingNotification{UserID: 2, ReceiverID: 1})
+	}
+
+	stats := client.Stats()
+	if stats.Closed || stats.Dropped != 10 {
+		t.Fatalf("Stats() = %+v, want open client with 10 dropped typing events", stats)
+	}
+}
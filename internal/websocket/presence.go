@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"github.com/squ1ky/talkify/internal/models"
+	"log"
+)
+
+// PresenceRequest represents presence frame changing user's away state
+type PresenceRequest struct {
+	Client *Client
+	UserID int
+	State  string
+}
+
+// processPresence handles presence frame and announces the new state
+func (h *Hub) processPresence(req *PresenceRequest) {
+	presence, err := h.presence.Update(req.UserID, models.PresenceUpdateRequest{State: req.State})
+	if err != nil {
+		req.Client.sendError("Failed to update presence: " + err.Error())
+		log.Printf("Failed to update presence of user %d: %v", req.UserID, err)
+		return
+	}
+
+	presence.Resolve(true)
+	h.deliverPresence(presence, false)
+}
+
+// userOnline announces user who opened their first connection on this instance
+func (h *Hub) userOnline(userID int) {
+	presence, err := h.presence.Get(userID)
+	if err != nil {
+		log.Printf("Failed to get presence of user %d: %v", userID, err)
+		return
+	}
+
+	presence.Resolve(true)
+	h.deliverPresence(presence, false)
+}
+
+// userOffline records last seen time of user whose last connection on this instance closed
+// Nothing is announced while user is still connected to another instance
+func (h *Hub) userOffline(userID int) {
+	if h.cluster != nil {
+		if online, err := h.cluster.IsOnline(userID); err == nil && online {
+			return
+		}
+	}
+
+	presence, err := h.presence.MarkSeen(userID)
+	if err != nil {
+		log.Printf("Failed to record last seen time of user %d: %v", userID, err)
+		return
+	}
+
+	presence.Resolve(false)
+	h.deliverPresence(presence, false)
+}
+
+// deliverPresence sends presence to user's contacts and user's own connections on this instance
+// Presence from this instance is also passed to the cluster
+func (h *Hub) deliverPresence(presence *models.Presence, remote bool) {
+	contacts, err := h.presence.GetContacts(presence.UserID)
+	if err != nil {
+		log.Printf("Failed to get contacts of user %d: %v", presence.UserID, err)
+	}
+
+	for _, userID := range append(contacts, presence.UserID) {
+		h.forEachClient(userID, func(client *Client) {
+			client.sendPresence(presence)
+		})
+	}
+
+	if !remote {
+		h.publishCluster(clusterKindPresence, presence)
+	}
+}
+
+// PublishPresence queues presence change for delivery to user's contacts
+// This can be called from outside (e.g., REST API)
+func (h *Hub) PublishPresence(presence *models.Presence) {
+	h.PresenceChanges <- presence
+}
+
+// GetPresence returns presence of users, unknown IDs are skipped
+// Can be called from any goroutine
+func (h *Hub) GetPresence(userIDs []int) ([]models.Presence, error) {
+	presences, err := h.presence.GetMany(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	online := h.onlineUsers(userIDs)
+	for i := range presences {
+		presences[i].Resolve(online[presences[i].UserID])
+	}
+
+	return presences, nil
+}
+
+// onlineUsers reports which of the users are connected to any instance
+func (h *Hub) onlineUsers(userIDs []int) map[int]bool {
+	online := make(map[int]bool, len(userIDs))
+
+	if len(userIDs) == 1 {
+		online[userIDs[0]] = h.IsUserOnline(userIDs[0])
+		return online
+	}
+
+	for _, userID := range h.GetOnlineUsers() {
+		online[userID] = true
+	}
+	return online
+}
@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"github.com/squ1ky/talkify/internal/models"
+	"log"
+)
+
+// ReactionRequest represents react or unreact frame that needs to be processed by Hub
+type ReactionRequest struct {
+	Client    *Client
+	UserID    int
+	MessageID int
+	Emoji     string
+	Remove    bool
+}
+
+// processReaction adds or removes reaction and notifies participants if anything changed
+func (h *Hub) processReaction(req *ReactionRequest) {
+	update := h.messageService.React
+	if req.Remove {
+		update = h.messageService.Unreact
+	}
+
+	reaction, err := update(req.UserID, req.MessageID, req.Emoji)
+	if err != nil {
+		req.Client.sendError("Failed to update reaction: " + err.Error())
+		log.Printf("Failed to update reaction of user %d on message %d: %v", req.UserID, req.MessageID, err)
+		return
+	}
+
+	if reaction != nil {
+		h.deliverReaction(reaction, false)
+	}
+}
+
+// deliverReaction sends reaction change to online participants of the message on this instance
+// Changes made on this instance are also passed to the cluster
+func (h *Hub) deliverReaction(reaction *models.ReactionResponse, remote bool) {
+	participants, err := h.messageService.GetParticipants(&models.MessageResponse{
+		ID:             reaction.MessageID,
+		SenderID:       reaction.SenderID,
+		ReceiverID:     reaction.ReceiverID,
+		ConversationID: reaction.ConversationID,
+	})
+	if err != nil {
+		log.Printf("Failed to get participants of message %d: %v", reaction.MessageID, err)
+		return
+	}
+
+	for _, userID := range participants {
+		h.forEachClient(userID, func(client *Client) {
+			client.sendReaction(reaction)
+		})
+	}
+
+	if !remote {
+		h.publishCluster(clusterKindReaction, reaction)
+	}
+}
+
+// PublishReaction queues reaction change for delivery to message participants
+// This can be called from outside (e.g., REST API)
+func (h *Hub) PublishReaction(reaction *models.ReactionResponse) {
+	h.Reactions <- reaction
+}
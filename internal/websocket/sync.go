@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"github.com/squ1ky/talkify/internal/models"
+	"log"
+	"time"
+)
+
+const (
+	// maxReplayMessages caps number of messages replayed on resume
+	// Clients fetch the rest via GET /messages/sync
+	maxReplayMessages = 200
+
+	// replayHeadroom is room left in Send buffer for sync_complete and live traffic queued with the replay
+	replayHeadroom = 32
+)
+
+// SyncRequest represents client's request to replay messages it missed
+type SyncRequest struct {
+	Client  *Client
+	Since   time.Time
+	SinceID int
+}
+
+// SyncStatus represents result of replay sent with sync_complete event
+// Count and LastMessageID describe replayed new messages; with Refetch the client should reload its history,
+// because not every older message changed meanwhile was replayed
+type SyncStatus struct {
+	Count         int  `json:"count"`
+	LastMessageID int  `json:"last_message_id,omitempty"`
+	HasMore       bool `json:"has_more"`
+	Refetch       bool `json:"refetch,omitempty"`
+}
+
+// replayResult represents missed messages fetched outside Hub.Run
+// Replay holds message_updated frames of older messages followed by new messages
+type replayResult struct {
+	req      *SyncRequest
+	replay   []OutgoingMessage
+	receipts []models.ReceiptResponse
+	status   *SyncStatus
+	err      error
+}
+
+// replay holds live traffic of the client and fetches missed messages in a separate goroutine
+// Runs inside Hub.Run; the result is handed back through replays, so database access doesn't block the Hub
+func (h *Hub) replay(req *SyncRequest) {
+	if !req.Client.hold() {
+		req.Client.sendError("Sync is already in progress")
+		return
+	}
+
+	go func() {
+		h.replays <- h.fetchReplay(req)
+	}()
+}
+
+// fetchReplay loads messages missed by the client and marks those sent to it as delivered
+func (h *Hub) fetchReplay(req *SyncRequest) *replayResult {
+	result := &replayResult{req: req}
+	userID := req.Client.UserID
+
+	resp, err := h.messageService.GetMessagesSince(userID, req.Since, req.SinceID, h.replayLimit())
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	result.status = &SyncStatus{
+		Count:   len(resp.Messages),
+		HasMore: resp.HasMore,
+		Refetch: resp.Refetch,
+	}
+
+	for i := range resp.Updated {
+		message := resp.Updated[i].ToMessageResponse()
+		result.replay = append(result.replay, OutgoingMessage{
+			Type:      EventMessageUpdated,
+			Message:   &message,
+			Timestamp: time.Now(),
+		})
+	}
+
+	for i := range resp.Messages {
+		message := resp.Messages[i].ToMessageResponse()
+		result.replay = append(result.replay, OutgoingMessage{
+			Type:      EventMessage,
+			Message:   &message,
+			Timestamp: time.Now(),
+		})
+		result.status.LastMessageID = message.ID
+
+		if message.SenderID == userID || message.Status != models.StatusSent {
+			continue
+		}
+
+		receipt, err := h.messageService.MarkDelivered(&message, userID)
+		if err != nil {
+			log.Printf("Failed to mark message %d delivered: %v", message.ID, err)
+			continue
+		}
+		if receipt != nil {
+			result.receipts = append(result.receipts, *receipt)
+		}
+	}
+
+	return result
+}
+
+// replayLimit returns number of messages replayed on resume
+// Replay is queued at once, so it must fit Send buffer, otherwise the client is disconnected as slow consumer
+func (h *Hub) replayLimit() int {
+	return max(1, min(maxReplayMessages, h.config.SendBufferSize-replayHeadroom))
+}
+
+// cutReplay keeps first n replayed frames and returns status describing them
+// Dropped new messages are fetched via GET /messages/sync; dropped updates make the client reload its history
+func cutReplay(replay []OutgoingMessage, status *SyncStatus, n int) ([]OutgoingMessage, *SyncStatus) {
+	cut := *status
+	cut.Count = 0
+	cut.LastMessageID = 0
+
+	for _, outgoingMsg := range replay[:n] {
+		if outgoingMsg.Type == EventMessage {
+			cut.Count++
+			cut.LastMessageID = outgoingMsg.Message.ID
+		}
+	}
+	for _, outgoingMsg := range replay[n:] {
+		if outgoingMsg.Type == EventMessage {
+			cut.HasMore = true
+		} else {
+			cut.Refetch = true
+		}
+	}
+
+	return replay[:n], &cut
+}
+
+// finishReplay sends replayed messages and sync_complete marker ahead of live traffic held meanwhile
+// Runs inside Hub.Run, so no live traffic is interleaved with replayed messages
+func (h *Hub) finishReplay(result *replayResult) {
+	client := result.req.Client
+
+	if result.err != nil {
+		client.release(nil, nil)
+		client.sendError("Failed to sync messages: " + result.err.Error())
+		log.Printf("Failed to replay messages for user %d: %v", client.UserID, result.err)
+		return
+	}
+
+	client.release(result.replay, result.status)
+
+	for i := range result.receipts {
+		h.deliverReceipt(&result.receipts[i])
+	}
+}
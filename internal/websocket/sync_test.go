@@ -0,0 +1,130 @@
+package websocket
+
+import (
+	"github.com/squ1ky/talkify/internal/config"
+	"github.com/squ1ky/talkify/internal/models"
+	"github.com/squ1ky/talkify/internal/services"
+	"testing"
+	"time"
+)
+
+// replayMessageService returns missed messages once the test lets the replay through
+type replayMessageService struct {
+	*services.MessageService
+	missed  []models.MessageWithUserResponse
+	proceed chan struct{}
+}
+
+func (s *replayMessageService) GetMessagesSince(userID int, since time.Time, sinceID, limit int) (*models.MessageSyncResponse, error) {
+	<-s.proceed
+	return &models.MessageSyncResponse{Messages: s.missed[:min(limit, len(s.missed))]}, nil
+}
+
+func TestReplayMakesRoomForTrafficHeldDuringSync(t *testing.T) {
+	db := newClosedDB(t)
+	messageService := &replayMessageService{
+		MessageService: newTestMessageService(db),
+		proceed:        make(chan struct{}),
+	}
+	for id := 1; id <= 200; id++ {
+		messageService.missed = append(messageService.missed, models.MessageWithUserResponse{
+			ID:     id,
+			Status: models.StatusRead,
+			Sender: models.UserResponse{ID: 2},
+		})
+	}
+	hub := startTestHub(db, messageService)
+
+	client := newTestClient(hub, 1)
+	hub.HandleSync <- &SyncRequest{Client: client}
+
+	// Live messages keep arriving while missed messages are loaded
+	const flood = 100
+	for i := 1; i <= flood; i++ {
+		hub.BroadcastMessage(1, &models.MessageResponse{ID: 1000 + i, SenderID: 2, ReceiverID: 1})
+	}
+	close(messageService.proceed)
+
+	replayed := 0
+	for {
+		got := receive(t, client)
+		if got.Type == EventSyncComplete {
+			if got.Sync.Count != replayed || !got.Sync.HasMore {
+				t.Fatalf("sync_complete = %+v after %d replayed messages, want has_more", got.Sync, replayed)
+			}
+			if replayed > 0 && got.Sync.LastMessageID != replayed {
+				t.Errorf("sync_complete last message = %d, want %d", got.Sync.LastMessageID, replayed)
+			}
+			break
+		}
+		if got.Type != EventMessage || got.Message.ID != replayed+1 {
+			t.Fatalf("frame %d = %s, want replayed message %d", replayed+1, got.Type, replayed+1)
+		}
+		replayed++
+	}
+
+	if want := hub.config.SendBufferSize - flood - 1; replayed != want {
+		t.Errorf("replayed %d messages, want %d that fit next to held traffic", replayed, want)
+	}
+
+	for i := 1; i <= flood; i++ {
+		if got := receive(t, client); got.Type != EventMessage || got.Message.ID != 1000+i {
+			t.Fatalf("held frame %d = %s, want live message %d", i, got.Type, 1000+i)
+		}
+	}
+	if stats := client.Stats(); stats.Closed {
+		t.Errorf("Stats() = %+v, want client kept open", stats)
+	}
+}
+
+func TestCutReplay(t *testing.T) {
+	frame := func(eventType string, id int) OutgoingMessage {
+		return OutgoingMessage{Type: eventType, Message: &models.MessageResponse{ID: id}}
+	}
+	replay := []OutgoingMessage{
+		frame(EventMessageUpdated, 1),
+		frame(EventMessageUpdated, 2),
+		frame(EventMessage, 10),
+		frame(EventMessage, 11),
+	}
+	status := &SyncStatus{Count: 2, LastMessageID: 11}
+
+	tests := []struct {
+		n    int
+		want SyncStatus
+	}{
+		{3, SyncStatus{Count: 1, LastMessageID: 10, HasMore: true}},
+		{2, SyncStatus{HasMore: true}},
+		{1, SyncStatus{HasMore: true, Refetch: true}},
+	}
+
+	for _, tt := range tests {
+		kept, got := cutReplay(replay, status, tt.n)
+		if len(kept) != tt.n || *got != tt.want {
+			t.Errorf("cutReplay(n=%d) = %d frames, %+v; want %d frames, %+v", tt.n, len(kept), *got, tt.n, tt.want)
+		}
+	}
+	if *status != (SyncStatus{Count: 2, LastMessageID: 11}) {
+		t.Errorf("cutReplay() changed original status to %+v", *status)
+	}
+}
+
+func TestReplayLimitFitsSendBuffer(t *testing.T) {
+	tests := []struct {
+		bufferSize int
+		want       int
+	}{
+		{256, maxReplayMessages},
+		{maxReplayMessages + replayHeadroom, maxReplayMessages},
+		{100, 100 - replayHeadroom},
+		{replayHeadroom, 1},
+		{1, 1},
+	}
+
+	for _, tt := range tests {
+		hub := &Hub{config: config.WebSocketConfig{SendBufferSize: tt.bufferSize}}
+		if got := hub.replayLimit(); got != tt.want {
+			t.Errorf("replayLimit() with buffer of %d = %d, want %d", tt.bufferSize, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package websocket
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// typingTimeout is how long typing state lives without a refreshing typing_start
+	typingTimeout = 6 * time.Second
+
+	// typingSweepInterval is how often Hub checks for expired typing state
+	typingSweepInterval = time.Second
+)
+
+// TypingRequest represents typing_start or typing_stop frame that needs to be relayed by Hub
+type TypingRequest struct {
+	UserID         int
+	ReceiverID     int
+	ConversationID int
+	Typing         bool
+}
+
+// TypingNotification represents typing state sent to client's browser
+type TypingNotification struct {
+	UserID         int    `json:"user_id"`
+	Username       string `json:"username"`
+	ReceiverID     int    `json:"receiver_id,omitempty"`
+	ConversationID int    `json:"conversation_id,omitempty"`
+}
+
+// typingKey identifies who is typing in which chat
+type typingKey struct {
+	userID         int
+	receiverID     int
+	conversationID int
+}
+
+// typingSession represents typing state of a user in one chat
+// Recipients are resolved when typing starts, so refreshes and typing_stop don't touch the database
+type typingSession struct {
+	recipients []int
+	expiresAt  time.Time
+}
+
+// processTyping updates typing state and relays changes to other participants
+// Typing state lives only in Hub memory and is never persisted
+func (h *Hub) processTyping(req *TypingRequest) {
+	key := typingKey{
+		userID:         req.UserID,
+		receiverID:     req.ReceiverID,
+		conversationID: req.ConversationID,
+	}
+
+	if !req.Typing {
+		h.stopTyping(key)
+		return
+	}
+
+	if session, ok := h.typing[key]; ok {
+		session.expiresAt = time.Now().Add(typingTimeout)
+		return
+	}
+
+	recipients, err := h.typingRecipients(key)
+	if err != nil {
+		log.Printf("Failed to relay typing of user %d: %v", key.userID, err)
+		return
+	}
+
+	h.typing[key] = &typingSession{
+		recipients: recipients,
+		expiresAt:  time.Now().Add(typingTimeout),
+	}
+	h.relayTyping(key, recipients, EventTypingStart)
+}
+
+// stopTyping clears typing state and notifies participants if user was typing
+func (h *Hub) stopTyping(key typingKey) {
+	session, ok := h.typing[key]
+	if !ok {
+		return
+	}
+
+	delete(h.typing, key)
+	h.relayTyping(key, session.recipients, EventTypingStop)
+}
+
+// stopUserTyping clears all typing state of a user, e.g. when their last connection closes
+func (h *Hub) stopUserTyping(userID int) {
+	for key := range h.typing {
+		if key.userID == userID {
+			h.stopTyping(key)
+		}
+	}
+}
+
+// expireTyping stops typing state of clients that went silent
+func (h *Hub) expireTyping(now time.Time) {
+	for key, session := range h.typing {
+		if now.After(session.expiresAt) {
+			h.stopTyping(key)
+		}
+	}
+}
+
+// typingRecipients returns users who see the typing indicator
+// Direct chats go straight to the peer; group members are loaded once per typing session
+func (h *Hub) typingRecipients(key typingKey) ([]int, error) {
+	if key.conversationID == 0 && key.receiverID != 0 {
+		return []int{key.receiverID}, nil
+	}
+	return h.messageService.GetRecipients(key.userID, key.receiverID, key.conversationID)
+}
+
+// relayTyping sends typing event to online recipients on this and other instances
+func (h *Hub) relayTyping(key typingKey, recipients []int, eventType string) {
+	notification := &TypingNotification{
+		UserID:         key.userID,
+		ReceiverID:     key.receiverID,
+		ConversationID: key.conversationID,
+	}
+	for client := range h.clients[key.userID] {
+		notification.Username = client.Username
+		break
+	}
+
+	h.sendTyping(recipients, eventType, notification)
+	h.publishTyping(eventType, notification, recipients)
+}
+
+// sendTyping sends typing event to recipients connected to this instance
+func (h *Hub) sendTyping(recipients []int, eventType string, notification *TypingNotification) {
+	for _, userID := range recipients {
+		h.forEachClient(userID, func(client *Client) {
+			client.sendTyping(eventType, notification)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package websocket
+
+import "testing"
+
+func TestTypingLoadsGroupMembersOncePerSession(t *testing.T) {
+	db := newClosedDB(t)
+	messageService := &groupMessageService{
+		MessageService: newTestMessageService(db),
+		members:        map[int][]int{7: {1, 2}},
+	}
+	hub := startTestHub(db, messageService)
+
+	newTestClient(hub, 1)
+	member := newTestClient(hub, 2)
+
+	hub.HandleTyping <- &TypingRequest{UserID: 1, ConversationID: 7, Typing: true}
+	hub.HandleTyping <- &TypingRequest{UserID: 1, ConversationID: 7, Typing: true}
+	hub.HandleTyping <- &TypingRequest{UserID: 1, ConversationID: 7, Typing: false}
+	hub.HandleTyping <- &TypingRequest{UserID: 1, ReceiverID: 2, Typing: true}
+
+	for _, want := range []string{EventTypingStart, EventTypingStop, EventTypingStart} {
+		if got := receive(t, member); got.Type != want {
+			t.Fatalf("member got %s, want %s", got.Type, want)
+		}
+	}
+
+	// Direct chat typing goes straight to the peer without a lookup
+	if messageService.lookups != 1 {
+		t.Errorf("group members loaded %d times, want once per typing session", messageService.lookups)
+	}
+}
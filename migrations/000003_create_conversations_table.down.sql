@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_messages_conversation_id;
+DELETE FROM messages WHERE conversation_id IS NOT NULL;
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS chk_messages_single_target;
+ALTER TABLE messages ALTER COLUMN receiver_id SET NOT NULL;
+ALTER TABLE messages DROP COLUMN IF EXISTS conversation_id;
+DROP INDEX IF EXISTS idx_conversation_members_user_id;
+DROP TABLE IF EXISTS conversation_members;
+DROP TABLE IF EXISTS conversations;
@@ -0,0 +1,33 @@
+CREATE TABLE conversations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE conversation_members (
+    conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX idx_conversation_members_user_id ON conversation_members(user_id);
+
+ALTER TABLE conversation_members
+ADD CONSTRAINT chk_conversation_members_role
+CHECK (role IN ('owner', 'admin', 'member'));
+
+ALTER TABLE messages
+ADD COLUMN conversation_id INTEGER REFERENCES conversations(id) ON DELETE CASCADE;
+
+ALTER TABLE messages
+ALTER COLUMN receiver_id DROP NOT NULL;
+
+ALTER TABLE messages
+ADD CONSTRAINT chk_messages_single_target
+CHECK ((receiver_id IS NULL) <> (conversation_id IS NULL));
+
+CREATE INDEX idx_messages_conversation_id ON messages(conversation_id, created_at);
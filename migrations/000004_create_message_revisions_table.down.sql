@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_message_revisions_message_id;
+DROP TABLE IF EXISTS message_revisions;
+ALTER TABLE messages DROP COLUMN IF EXISTS edited_at;
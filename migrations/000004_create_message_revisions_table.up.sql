@@ -0,0 +1,11 @@
+ALTER TABLE messages
+ADD COLUMN edited_at TIMESTAMP;
+
+CREATE TABLE message_revisions (
+    id SERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_revisions_message_id ON message_revisions(message_id, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_message_hides_user_id;
+DROP TABLE IF EXISTS message_hides;
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,11 @@
+ALTER TABLE messages
+ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE TABLE message_hides (
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hidden_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (message_id, user_id)
+);
+
+CREATE INDEX idx_message_hides_user_id ON message_hides(user_id);
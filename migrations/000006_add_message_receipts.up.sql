@@ -0,0 +1,7 @@
+ALTER TABLE messages
+ADD COLUMN delivered_at TIMESTAMP;
+
+ALTER TABLE messages
+ADD COLUMN read_at TIMESTAMP;
+
+CREATE INDEX idx_messages_unread ON messages(receiver_id, sender_id) WHERE read_at IS NULL;
@@ -0,0 +1,15 @@
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    idempotency_key VARCHAR(255) UNIQUE NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    partition_key VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    failed_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_pending ON outbox(id) WHERE published_at IS NULL AND failed_at IS NULL;
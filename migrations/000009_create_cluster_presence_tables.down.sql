@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_cluster_presence_user_id;
+DROP TABLE IF EXISTS cluster_presence;
+DROP TABLE IF EXISTS cluster_nodes;
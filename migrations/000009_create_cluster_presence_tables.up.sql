@@ -0,0 +1,13 @@
+CREATE TABLE cluster_nodes (
+    node_id VARCHAR(255) PRIMARY KEY,
+    heartbeat_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE cluster_presence (
+    node_id VARCHAR(255) NOT NULL REFERENCES cluster_nodes(node_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    connected_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (node_id, user_id)
+);
+
+CREATE INDEX idx_cluster_presence_user_id ON cluster_presence(user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_revoked_tokens_expires_at;
+DROP TABLE IF EXISTS revoked_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
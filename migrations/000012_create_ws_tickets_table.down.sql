@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ws_tickets_expires_at;
+DROP TABLE IF EXISTS ws_tickets;
@@ -0,0 +1,12 @@
+CREATE TABLE ws_tickets (
+    ticket_hash VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username VARCHAR(50) NOT NULL,
+    token_id VARCHAR(64) NOT NULL,
+    token_version INTEGER NOT NULL,
+    token_expires_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ws_tickets_expires_at ON ws_tickets(expires_at);
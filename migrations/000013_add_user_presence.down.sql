@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS status_text;
+ALTER TABLE users DROP COLUMN IF EXISTS away;
+ALTER TABLE users DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN last_seen_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN away BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN status_text VARCHAR(100) NOT NULL DEFAULT '';
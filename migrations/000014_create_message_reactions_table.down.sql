@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_message_reactions_user_id;
+DROP INDEX IF EXISTS idx_message_reactions_message_emoji;
+DROP TABLE IF EXISTS message_reactions;
@@ -0,0 +1,10 @@
+CREATE TABLE message_reactions (
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (message_id, user_id, emoji)
+);
+
+CREATE INDEX idx_message_reactions_message_emoji ON message_reactions(message_id, emoji);
+CREATE INDEX idx_message_reactions_user_id ON message_reactions(user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_messages_updated_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,8 @@
+ALTER TABLE messages
+ADD COLUMN updated_at TIMESTAMP;
+
+UPDATE messages
+SET updated_at = GREATEST(edited_at, deleted_at)
+WHERE edited_at IS NOT NULL OR deleted_at IS NOT NULL;
+
+CREATE INDEX idx_messages_updated_at ON messages(updated_at) WHERE updated_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_outbox_processed;
+DROP INDEX IF EXISTS idx_outbox_pending_partition;
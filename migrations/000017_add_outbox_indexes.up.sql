@@ -0,0 +1,3 @@
+CREATE INDEX idx_outbox_pending_partition ON outbox(partition_key, id) WHERE published_at IS NULL AND failed_at IS NULL;
+
+CREATE INDEX idx_outbox_processed ON outbox((COALESCE(published_at, failed_at))) WHERE published_at IS NOT NULL OR failed_at IS NOT NULL;